@@ -0,0 +1,37 @@
+// Command mock-oidc runs a local OpenID provider for trying out social login.
+// Point a provider at it with, for example:
+//
+//	OIDC_PROVIDERS=mock
+//	OIDC_MOCK_ISSUER=http://localhost:9999
+//	OIDC_MOCK_CLIENT_ID=finora
+//	OIDC_MOCK_REDIRECT_URL=http://localhost:8080/auth/oidc/mock/callback
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/oidc/mockissuer"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9999", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9999", "issuer URL, must match the listen address")
+	clientID := flag.String("client-id", "finora", "client id to accept")
+	email := flag.String("email", "mock.user@example.com", "email of the default signed-in user")
+	flag.Parse()
+
+	handler, err := mockissuer.New(*issuer, *clientID, mockissuer.User{
+		Subject:       "mock-user",
+		Email:         *email,
+		EmailVerified: true,
+		Name:          "Mock User",
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("mock OpenID provider listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, handler))
+}
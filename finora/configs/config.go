@@ -1,33 +1,310 @@
 package configs
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
+// Mail configures outgoing email. Backend is smtp, file (a maildir under
+// Dir, for development) or memory. URL is the public base URL used in links.
 type Mail struct {
-	SecretKey string `mapstructure:"SECRETKEY"`
-	From      string `mapstructure:"FROM"`
-	URL       string `mapstructure:"URL"`
+	Backend      string
+	From         string
+	URL          string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTLS      string // starttls, tls or none
+	Dir          string
 }
 
+// JWT configures token signing. Keys are read from PEM files in KeyDir,
+// named <kid>.pem, and/or a single PEM given inline in PrivateKey. KeyDir
+// must be an absolute path, shared by every instance so they all sign with
+// the same keys, e.g. /etc/finora/keys. GenerateKey lets a development setup
+// start with a relative or empty KeyDir and creates a key there; without it
+// the server refuses to start until a key has been provisioned. A key added to
+// KeyDir is used for verification at once but only signs tokens two minutes
+// later, once every instance has loaded it.
+type JWT struct {
+	Issuer           string
+	Algorithm        string // RS256 or EdDSA, used for newly generated keys
+	KeyDir           string
+	PrivateKey       string
+	KeyID            string
+	RotationInterval time.Duration // 0 disables rotation
+	GenerateKey      bool
+}
+
+// OTP configures one-time code storage. HMACKey keys the hashes stored for
+// codes, so a leaked otps table cannot be brute-forced offline. It must be
+// the same on every instance and at least 32 bytes long.
+type OTP struct {
+	HMACKey string
+}
+
+// OIDCProvider configures one OpenID Connect identity provider. Name is used
+// in URLs, e.g. /auth/oidc/google/login.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Admin names the account promoted to admin on startup when no admin exists
+// yet. Password is only used if the account has to be created. An existing
+// account must have a verified email or startup fails.
+type Admin struct {
+	Email    string
+	Password string
+}
+
+// Audit configures how long audit events are kept. A zero Retention keeps
+// them forever.
+type Audit struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// Password configures password hashing. Algorithm is used for new hashes;
+// existing hashes from any supported algorithm still verify. Workers bounds
+// how many hashes run at once and QueueTimeout how long a request waits for
+// a free worker.
+type Password struct {
+	Algorithm         string // argon2id or bcrypt
+	Argon2Memory      int    // KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+	BcryptCost        int
+	Workers           int
+	QueueTimeout      time.Duration
+}
+
+// PasswordPolicy sets the rules new passwords must meet. MinEntropy is in
+// bits; 0 disables the check. BreachedDir points at a local breached-password
+// dataset, see policy.BreachedSet; empty disables the check.
+type PasswordPolicy struct {
+	MinLength        int
+	MinEntropy       float64
+	BreachedDir      string
+	BreachedMinCount int
+}
+
+// SMS configures text message delivery. Backend is http, log or memory;
+// leaving it empty disables SMS, and codes then go out by email only. Each
+// number may receive at most MaxPerNumberHour and MaxPerNumberDay messages.
+type SMS struct {
+	Backend          string
+	From             string
+	HTTPURL          string
+	HTTPToken        string
+	HTTPUsername     string
+	HTTPPassword     string
+	Timeout          time.Duration
+	MaxPerNumberHour int
+	MaxPerNumberDay  int
+}
+
+// Outbox configures delivery of queued email. A failed send is retried after
+// BaseBackoff, doubling up to MaxBackoff, until MaxAttempts is reached and
+// the email is dead-lettered. Sent and dead-lettered emails are deleted after
+// SentRetention.
+type Outbox struct {
+	PollInterval  time.Duration
+	BatchSize     int
+	MaxAttempts   int
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	SentRetention time.Duration
+}
+
+// Account configures data exports and account deletion. Exports are written
+// under ExportDir and kept for ExportTTL; deletions run DeletionGrace after
+// they are requested. JobInterval is how often both are checked.
+type Account struct {
+	ExportDir     string
+	ExportTTL     time.Duration
+	DeletionGrace time.Duration
+	JobInterval   time.Duration
+}
 
 type Config struct {
-	DBNAME   string
-	DBUSER   string
-	PASSWORD string
-	HOST     string
-	PORT     string
-	Mail    Mail
+	DBNAME         string
+	DBUSER         string
+	PASSWORD       string
+	HOST           string
+	PORT           string
+	Mail           Mail
+	JWT            JWT
+	OTP            OTP
+	OIDC           []OIDCProvider
+	Admin          Admin
+	Audit          Audit
+	Password       Password
+	PasswordPolicy PasswordPolicy
+	Account        Account
+	Outbox         Outbox
+	SMS            SMS
+
+	// TrustedProxies lists the addresses or CIDR ranges of reverse proxies
+	// whose X-Forwarded-For header is believed. Empty trusts none, so the
+	// client address is always the address of the connection. Every per-IP
+	// limit is keyed on that address.
+	TrustedProxies []string
 }
 
 func GetConfig() Config {
 	return Config{
-		DBNAME: os.Getenv("DBNAME"),
-		DBUSER: os.Getenv("DBUSER"),
+		DBNAME:   os.Getenv("DBNAME"),
+		DBUSER:   os.Getenv("DBUSER"),
 		PASSWORD: os.Getenv("PASSWORD"),
-		HOST: os.Getenv("HOST"),
-		PORT: os.Getenv("PORT"),
+		HOST:     os.Getenv("HOST"),
+		PORT:     os.Getenv("PORT"),
 		Mail: Mail{
-			SecretKey: os.Getenv("SECRETKEY"),
-			
+			Backend:      getEnv("MAIL_BACKEND", "smtp"),
+			From:         os.Getenv("MAIL_FROM"),
+			URL:          os.Getenv("URL"),
+			SMTPHost:     os.Getenv("SMTP_HOST"),
+			SMTPPort:     getEnvInt("SMTP_PORT", 587),
+			SMTPUsername: os.Getenv("SMTP_USERNAME"),
+			SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+			SMTPTLS:      getEnv("SMTP_TLS", "starttls"),
+			Dir:          getEnv("MAIL_DIR", "mail"),
+		},
+		JWT: JWT{
+			Issuer:    getEnv("JWT_ISSUER", "finora"),
+			Algorithm: getEnv("JWT_ALGORITHM", "EdDSA"),
+			KeyDir:    os.Getenv("JWT_KEY_DIR"),
+			// Allow PEMs squashed onto one line with literal \n
+			PrivateKey:       strings.ReplaceAll(os.Getenv("JWT_PRIVATE_KEY"), `\n`, "\n"),
+			KeyID:            getEnv("JWT_KEY_ID", "default"),
+			RotationInterval: getEnvDuration("JWT_ROTATION_INTERVAL", 0),
+			GenerateKey:      getEnvBool("JWT_GENERATE_KEY", false),
+		},
+		OTP: OTP{
+			HMACKey: os.Getenv("OTP_HMAC_KEY"),
+		},
+		OIDC: getOIDCProviders(),
+		Admin: Admin{
+			Email:    os.Getenv("BOOTSTRAP_ADMIN_EMAIL"),
+			Password: os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"),
+		},
+		Audit: Audit{
+			Retention:     getEnvDuration("AUDIT_RETENTION", 0),
+			PurgeInterval: getEnvDuration("AUDIT_PURGE_INTERVAL", 24*time.Hour),
+		},
+		Password: Password{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+			Argon2Memory:      getEnvInt("ARGON2_MEMORY_KIB", 64*1024),
+			Argon2Iterations:  getEnvInt("ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getEnvInt("ARGON2_PARALLELISM", 2),
+			BcryptCost:        getEnvInt("BCRYPT_COST", 12),
+			Workers:           getEnvInt("PASSWORD_HASH_WORKERS", 0),
+			QueueTimeout:      getEnvDuration("PASSWORD_HASH_QUEUE_TIMEOUT", 5*time.Second),
 		},
+		PasswordPolicy: PasswordPolicy{
+			MinLength:        getEnvInt("PASSWORD_MIN_LENGTH", 10),
+			MinEntropy:       float64(getEnvInt("PASSWORD_MIN_ENTROPY_BITS", 45)),
+			BreachedDir:      os.Getenv("PASSWORD_BREACHED_DIR"),
+			BreachedMinCount: getEnvInt("PASSWORD_BREACHED_MIN_COUNT", 1),
+		},
+		SMS: SMS{
+			Backend:          os.Getenv("SMS_BACKEND"),
+			From:             os.Getenv("SMS_FROM"),
+			HTTPURL:          os.Getenv("SMS_HTTP_URL"),
+			HTTPToken:        os.Getenv("SMS_HTTP_TOKEN"),
+			HTTPUsername:     os.Getenv("SMS_HTTP_USERNAME"),
+			HTTPPassword:     os.Getenv("SMS_HTTP_PASSWORD"),
+			Timeout:          getEnvDuration("SMS_HTTP_TIMEOUT", 10*time.Second),
+			MaxPerNumberHour: getEnvInt("SMS_MAX_PER_NUMBER_HOUR", 3),
+			MaxPerNumberDay:  getEnvInt("SMS_MAX_PER_NUMBER_DAY", 5),
+		},
+		Outbox: Outbox{
+			PollInterval:  getEnvDuration("EMAIL_POLL_INTERVAL", 5*time.Second),
+			BatchSize:     getEnvInt("EMAIL_BATCH_SIZE", 20),
+			MaxAttempts:   getEnvInt("EMAIL_MAX_ATTEMPTS", 8),
+			BaseBackoff:   getEnvDuration("EMAIL_RETRY_BACKOFF", 30*time.Second),
+			MaxBackoff:    getEnvDuration("EMAIL_RETRY_MAX_BACKOFF", time.Hour),
+			SentRetention: getEnvDuration("EMAIL_SENT_RETENTION", 7*24*time.Hour),
+		},
+		Account: Account{
+			ExportDir:     getEnv("EXPORT_DIR", "exports"),
+			ExportTTL:     getEnvDuration("EXPORT_TTL", 7*24*time.Hour),
+			DeletionGrace: getEnvDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+			JobInterval:   getEnvDuration("ACCOUNT_JOB_INTERVAL", time.Hour),
+		},
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+	}
+}
+
+// getEnvList reads a comma separated list, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getOIDCProviders reads OIDC_PROVIDERS, a comma separated list of names, and
+// for each name the OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET,
+// _REDIRECT_URL and optional _SCOPES variables.
+func getOIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		})
+	}
+	return providers
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
 	}
-}
\ No newline at end of file
+	return value
+}
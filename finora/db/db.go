@@ -12,16 +12,39 @@ import (
 func InitDB(cfg configs.Config)  (*gorm.DB, error) {
 	psqlInfo := fmt.Sprintf("user=%s dbname=%s password=%s host=%s port=%s", cfg.DBUSER, cfg.DBNAME, cfg.PASSWORD, cfg.HOST, cfg.PORT)
 	// psqlInfo:="user=postgres dbname=company password=123 host=localhost port=5432 "
-	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{TranslateError: true})
 	if err != nil {
-		fmt.Println("error in db connection", err)
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
-	db.AutoMigrate(&domain.User{})
+	// Email used to be a plain unique column. Users signing in with a phone
+	// number have no email, so uniqueness is now enforced by partial indexes.
+	if db.Migrator().HasConstraint(&domain.User{}, "uni_users_email") {
+		if err := db.Migrator().DropConstraint(&domain.User{}, "uni_users_email"); err != nil {
+			return nil, fmt.Errorf("drop users email constraint: %w", err)
+		}
+	}
 
-	// Create a admin in postgress database using terminal with credential of name and password;
-	// db.AutoMigrate(&domain.Admin{})
+	err = db.AutoMigrate(&domain.User{}, &domain.Otp{}, &domain.RefreshToken{}, &domain.Session{}, &domain.TwoFactor{}, &domain.RecoveryCode{}, &domain.MFAChallenge{}, &domain.LoginThrottle{}, &domain.ExternalIdentity{}, &domain.OAuthState{}, &domain.PersonalAccessToken{}, &domain.AuditEvent{}, &domain.DataExport{}, &domain.OutboxEmail{}, &domain.SMSMessage{}, &domain.Notification{})
+	if err != nil {
+		return nil, fmt.Errorf("migrate database: %w", err)
+	}
 
+	// The audit log is append-only; only the retention purge may delete rows
+	for _, stmt := range []string{
+		`CREATE OR REPLACE FUNCTION audit_events_no_update() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit events cannot be modified';
+END;
+$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events`,
+		`CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_no_update()`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			return nil, fmt.Errorf("install audit log trigger: %w", err)
+		}
+	}
 
 	return db, nil
 
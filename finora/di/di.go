@@ -4,26 +4,87 @@ import (
 	"github.com/ayyoob-k-a/finora/configs"
 	"github.com/ayyoob-k-a/finora/db"
 	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/hasher"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/oidc"
+	"github.com/ayyoob-k-a/finora/policy"
 	"github.com/ayyoob-k-a/finora/repo"
 	"github.com/ayyoob-k-a/finora/routes"
 	"github.com/ayyoob-k-a/finora/server"
+	"github.com/ayyoob-k-a/finora/sms"
 	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/ayyoob-k-a/finora/utils"
 )
 
 func InitDI(cfg configs.Config) error {
+	// Refuse to start without a key to sign tokens with
+	if err := utils.InitSigningKeys(cfg.JWT); err != nil {
+		return err
+	}
+
+	if err := utils.InitOTPKey(cfg.OTP); err != nil {
+		return err
+	}
+
 	// Initialize the database connection
 	db, err := db.InitDB(cfg)
 	if err != nil {
 		return err
 	}
 
-	ginServer := server.InitRouter()
+	ginServer, err := server.InitRouter(cfg.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if err := handler.RegisterValidators(); err != nil {
+		return err
+	}
 
 	// Here you can set up your server with the database connection
-	repoInstance := repo.NewRepo(db)
-	usecase := usecase.NewUsecase(repoInstance, cfg.Mail)
+	passwordHasher, err := hasher.New(cfg.Password)
+	if err != nil {
+		return err
+	}
+	repoInstance := repo.NewRepo(db, passwordHasher)
+	passwordPolicy, err := policy.NewPassword(cfg.PasswordPolicy)
+	if err != nil {
+		return err
+	}
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		return err
+	}
+	templates, err := mailer.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	smsSender, err := sms.New(cfg.SMS)
+	if err != nil {
+		return err
+	}
+	usecase := usecase.NewUsecase(repoInstance, cfg.Mail, mail, templates, smsSender, cfg.SMS, oidc.NewProviders(cfg.OIDC), passwordPolicy, cfg.Account)
+	if err := usecase.BootstrapAdmin(cfg.Admin); err != nil {
+		return err
+	}
+	go usecase.RunAuditRetention(cfg.Audit)
+	if err := usecase.ResumeDataExports(); err != nil {
+		return err
+	}
+	go usecase.RunAccountJobs()
+	go usecase.RunEmailDispatcher(cfg.Outbox)
 	handler := handler.NewHandler(usecase)
-	routes.AuthRoutes(ginServer, handler)
+	authMiddleware := middleware.Auth(repoInstance)
+	routes.WellKnownRoutes(ginServer, handler)
+	routes.AuthRoutes(ginServer, handler, authMiddleware)
+	routes.UserRoutes(ginServer, handler, authMiddleware)
+	routes.SessionRoutes(ginServer, handler, authMiddleware)
+	routes.TwoFactorRoutes(ginServer, handler, authMiddleware)
+	routes.OIDCRoutes(ginServer, handler, authMiddleware)
+	routes.PATRoutes(ginServer, handler, authMiddleware)
+	routes.AdminRoutes(ginServer, handler, authMiddleware)
+	routes.AccountRoutes(ginServer, handler, authMiddleware)
+	routes.NotificationRoutes(ginServer, handler, authMiddleware)
 	server.StartServer(ginServer)
 
 	return nil
@@ -0,0 +1,61 @@
+package domain
+
+import "time"
+
+// Audit actions
+const (
+	AuditSignup           = "auth.signup"
+	AuditLogin            = "auth.login"
+	AuditLoginFailed      = "auth.login_failed"
+	AuditAccountLocked    = "auth.account_locked"
+	AuditAccountUnlocked  = "auth.account_unlocked"
+	AuditTokenRefresh     = "auth.token_refresh"
+	AuditRefreshReuse     = "auth.refresh_token_reuse"
+	AuditPasswordReset    = "auth.password_reset"
+	AuditLogout           = "auth.logout"
+	AuditSessionRevoke    = "auth.session_revoke"
+	AuditLogoutAll        = "auth.logout_all"
+	AuditTwoFactorEnable  = "auth.2fa_enable"
+	AuditTwoFactorDisable = "auth.2fa_disable"
+	AuditPATCreate        = "auth.pat_create"
+	AuditPATRevoke        = "auth.pat_revoke"
+	AuditIdentityLink     = "auth.identity_link"
+	AuditIdentityUnlink   = "auth.identity_unlink"
+	AuditProfileUpdate    = "account.profile_update"
+	AuditEmailChange      = "account.email_change"
+	AuditPhoneChange      = "account.phone_change"
+	AuditPasswordChange   = "account.password_change"
+	AuditDataExport       = "account.data_export"
+	AuditDeletionRequest  = "account.deletion_request"
+	AuditDeletionCancel   = "account.deletion_cancel"
+	AuditAccountDeleted   = "account.deleted"
+
+	AuditAdminVerifyUser         = "admin.user.verify"
+	AuditAdminDisableUser        = "admin.user.disable"
+	AuditAdminEnableUser         = "admin.user.enable"
+	AuditAdminResetTwoFactor     = "admin.user.reset_2fa"
+	AuditAdminRevokeSessions     = "admin.user.revoke_sessions"
+	AuditAdminResendVerification = "admin.user.resend_verification"
+	AuditAdminSetRole            = "admin.user.set_role"
+	AuditAdminRetryEmail         = "admin.email.retry"
+)
+
+// Audit target types
+const (
+	AuditTargetUser  = "user"
+	AuditTargetEmail = "email"
+)
+
+// AuditEvent records who did what to whom. Events are only ever appended;
+// the only deletion is the retention purge of old events.
+type AuditEvent struct {
+	ID         int            `json:"id" gorm:"primaryKey"`
+	ActorID    *int           `json:"actor_id" gorm:"index"`
+	Action     string         `json:"action" gorm:"index"`
+	TargetType string         `json:"target_type"`
+	TargetID   *int           `json:"target_id" gorm:"index"`
+	IP         string         `json:"ip"`
+	UserAgent  string         `json:"user_agent"`
+	Payload    map[string]any `json:"payload" gorm:"type:jsonb;serializer:json"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"index"`
+}
@@ -1,12 +1,21 @@
 package domain
 
+import "time"
+
 type User struct {
-	ID         int    `json:"id" gorm:"primaryKey"`
-	Email      string `json:"email" gorm:"unique"`
-	Password   string `json:"password"`
-	Phone      string `json:"phone"`
-	Username   string `json:"username"`
-	IsVerified bool   `json:"is_verified"`
-	CreatedAt  string `json:"created_at"`
-	Otp        int    `json:"otp"`
+	ID         int        `json:"id" gorm:"primaryKey"`
+	Email      string     `json:"email" gorm:"uniqueIndex:idx_users_email,where:email <> ''"`
+	Password   string     `json:"password"`
+	Phone      string     `json:"phone" gorm:"uniqueIndex:idx_users_phone,where:phone <> ''"`
+	Username   string     `json:"username"`
+	IsVerified bool       `json:"is_verified"`
+	Role       string     `json:"role" gorm:"not null;default:user"`
+	Locale     string     `json:"locale" gorm:"not null;default:en"`
+	DisabledAt *time.Time `json:"disabled_at"`
+	// DeletionScheduledAt is when a requested account deletion will run.
+	// AnonymizedAt is set once it has; the row is kept so records shared
+	// with other users still point at it.
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	AnonymizedAt        *time.Time `json:"anonymized_at"`
+	CreatedAt           string     `json:"created_at"`
 }
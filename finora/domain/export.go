@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Data export states
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExport is a user's request for a copy of their data. The archive is
+// written to FilePath and removed once ExpiresAt passes. A user has at most
+// one pending export.
+type DataExport struct {
+	ID          int        `json:"id" gorm:"primaryKey"`
+	UserID      int        `json:"user_id" gorm:"index;uniqueIndex:idx_data_exports_pending,where:status = 'pending'"`
+	Status      string     `json:"status" gorm:"index"`
+	FilePath    string     `json:"-"`
+	Size        int64      `json:"size"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
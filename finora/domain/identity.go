@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// ExternalIdentity links a user to an account at an OpenID provider
+type ExternalIdentity struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	UserID    int       `json:"user_id" gorm:"index"`
+	Provider  string    `json:"provider" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Subject   string    `json:"subject" gorm:"uniqueIndex:idx_identity_provider_subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OAuthState is an authorization request in flight. It is deleted when the
+// provider redirects back, so every state value is accepted only once.
+// LinkUserID is set when a signed-in user is attaching a provider.
+type OAuthState struct {
+	ID           int       `json:"id" gorm:"primaryKey"`
+	State        string    `json:"-" gorm:"uniqueIndex"`
+	Provider     string    `json:"provider"`
+	Nonce        string    `json:"-"`
+	CodeVerifier string    `json:"-"`
+	LinkUserID   int       `json:"link_user_id"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
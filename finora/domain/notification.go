@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// Notification types
+const (
+	NotificationFriendRequest  = "friend_request"
+	NotificationGroupExpense   = "group_expense"
+	NotificationEMIDue         = "emi_due"
+	NotificationBudgetExceeded = "budget_exceeded"
+	NotificationSecurityAlert  = "security_alert"
+)
+
+var NotificationTypes = []string{
+	NotificationFriendRequest,
+	NotificationGroupExpense,
+	NotificationEMIDue,
+	NotificationBudgetExceeded,
+	NotificationSecurityAlert,
+}
+
+// Notification is an in-app message for one user. Payload carries whatever
+// the publishing module needs for clients to render or link the notification,
+// such as a group or EMI id. ReadAt is nil until the user marks it read.
+type Notification struct {
+	ID        int            `json:"id" gorm:"primaryKey;index:idx_notifications_user,priority:2"`
+	UserID    int            `json:"-" gorm:"not null;index:idx_notifications_user,priority:1"`
+	Type      string         `json:"type" gorm:"not null"`
+	Title     string         `json:"title"`
+	Body      string         `json:"body"`
+	Payload   map[string]any `json:"payload" gorm:"type:jsonb;serializer:json"`
+	ReadAt    *time.Time     `json:"read_at"`
+	CreatedAt time.Time      `json:"created_at"`
+}
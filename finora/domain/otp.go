@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+const (
+	OtpPurposeVerify        = "verify"
+	OtpPurposePasswordReset = "password_reset"
+	OtpPurposeLogin         = "login"
+	OtpPurposeChangeEmail   = "change_email"
+	OtpPurposeChangePhone   = "change_phone"
+	OtpPurposeReauth        = "reauth"
+)
+
+// Otp is a one-time code issued to an identifier (email or phone). Only the
+// hash of the code is stored.
+type Otp struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	UserID     int       `json:"user_id" gorm:"index"`
+	Identifier string    `json:"identifier" gorm:"index"`
+	Purpose    string    `json:"purpose" gorm:"index"`
+	CodeHash   string    `json:"-"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Attempts   int       `json:"attempts"`
+	Consumed   bool      `json:"consumed"`
+	RequestIP  string    `json:"request_ip" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
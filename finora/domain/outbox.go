@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Outbox email states
+const (
+	EmailPending = "pending"
+	EmailSent    = "sent"
+	EmailDead    = "dead"
+)
+
+// OutboxEmail is an email waiting to be sent. It is written in the same
+// transaction as the change that caused it, so the email is sent if and only
+// if that change was committed. The body is cleared once it has been sent.
+// LeaseToken identifies the dispatcher currently sending it.
+type OutboxEmail struct {
+	ID            int        `json:"id" gorm:"primaryKey"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	HTML          string     `json:"-"`
+	Text          string     `json:"-"`
+	Template      string     `json:"template"`
+	Locale        string     `json:"locale"`
+	Version       string     `json:"version"`
+	Status        string     `json:"status" gorm:"index:idx_outbox_due,priority:1"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index:idx_outbox_due,priority:2"`
+	LeaseToken    string     `json:"-"`
+	LastError     string     `json:"last_error"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	SentAt        *time.Time `json:"sent_at"`
+}
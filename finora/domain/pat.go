@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Scopes a personal access token can be granted
+const (
+	ScopeProfileRead       = "profile:read"
+	ScopeTransactionsRead  = "transactions:read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeReportsRead       = "reports:read"
+)
+
+var PATScopes = []string{
+	ScopeProfileRead,
+	ScopeTransactionsRead,
+	ScopeTransactionsWrite,
+	ScopeReportsRead,
+}
+
+// PersonalAccessToken is a long-lived, scoped credential for scripts. Only a
+// hash of the token is stored; Prefix is kept so users can tell tokens apart.
+type PersonalAccessToken struct {
+	ID         int        `json:"id" gorm:"primaryKey"`
+	UserID     int        `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
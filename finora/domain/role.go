@@ -0,0 +1,45 @@
+package domain
+
+import "slices"
+
+// Roles a user can hold
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+var Roles = []string{RoleUser, RoleSupport, RoleAdmin}
+
+// Permissions checked by admin routes
+const (
+	PermUsersRead    = "users:read"
+	PermUsersManage  = "users:manage"
+	PermRolesManage  = "roles:manage"
+	PermSecurityRead = "security:read"
+	PermAuditRead    = "audit:read"
+	PermEmailsManage = "emails:manage"
+)
+
+// RolePermissions maps each role to what it may do. Plain users have no
+// administrative permissions.
+var RolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleSupport: {
+		PermUsersRead,
+		PermSecurityRead,
+	},
+	RoleAdmin: {
+		PermUsersRead,
+		PermUsersManage,
+		PermRolesManage,
+		PermSecurityRead,
+		PermAuditRead,
+		PermEmailsManage,
+	},
+}
+
+// RoleHasPermission reports whether role grants permission
+func RoleHasPermission(role, permission string) bool {
+	return slices.Contains(RolePermissions[role], permission)
+}
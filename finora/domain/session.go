@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// Session is a signed-in device. Every token pair issued by a login belongs
+// to exactly one session, and revoking the session invalidates them all.
+// AuthenticatedAt is when the user last proved who they are on this session,
+// at sign-in or by re-authenticating.
+type Session struct {
+	ID              int        `json:"id" gorm:"primaryKey"`
+	UserID          int        `json:"user_id" gorm:"index"`
+	Device          string     `json:"device"`
+	UserAgent       string     `json:"user_agent"`
+	IP              string     `json:"ip"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastSeenAt      time.Time  `json:"last_seen_at"`
+	AuthenticatedAt *time.Time `json:"authenticated_at"`
+	RevokedAt       *time.Time `json:"revoked_at"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// SMS delivery outcomes
+const (
+	SMSSent   = "sent"
+	SMSFailed = "failed"
+)
+
+// SMSPurposeContactChanged marks the warning sent when an account's contact
+// details change. One-time code messages use the OTP purpose instead.
+const SMSPurposeContactChanged = "contact_changed"
+
+// SMSMessage records one text message sent, or attempted, to a phone number.
+// The body is not kept since it may carry a one-time code. Failed attempts are
+// recorded too and count towards the per-number limits.
+type SMSMessage struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	Recipient string    `json:"recipient" gorm:"index:idx_sms_recipient_created,priority:1"`
+	Purpose   string    `json:"purpose"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_sms_recipient_created,priority:2"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// LoginThrottle tracks failed logins for one key, either an account
+// ("user:<id>") or a client address ("ip:<addr>"). Failures counts the current
+// streak; TotalFailures and LockCount are lifetime counters for admins.
+type LoginThrottle struct {
+	ID            int        `json:"id" gorm:"primaryKey"`
+	Key           string     `json:"key" gorm:"uniqueIndex"`
+	Failures      int        `json:"failures"`
+	TotalFailures int        `json:"total_failures"`
+	LockCount     int        `json:"lock_count"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	UnlockJTI     string     `json:"-"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
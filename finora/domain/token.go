@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// RefreshToken is the server-side record of an issued refresh token. Tokens
+// rotated from one another share a Family; ParentJTI points at the token that
+// was exchanged to obtain this one.
+type RefreshToken struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index"`
+	SessionID int        `json:"session_id" gorm:"index"`
+	Family    string     `json:"family" gorm:"index"`
+	JTI       string     `json:"jti" gorm:"uniqueIndex"`
+	ParentJTI string     `json:"parent_jti"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// TwoFactor holds a user's TOTP secret. The row exists but is not Enabled
+// between enrollment and confirmation.
+type TwoFactor struct {
+	ID             int        `json:"id" gorm:"primaryKey"`
+	UserID         int        `json:"user_id" gorm:"uniqueIndex"`
+	Secret         string     `json:"-"`
+	Enabled        bool       `json:"enabled"`
+	ConfirmedAt    *time.Time `json:"confirmed_at"`
+	LastUsedStep   int64      `json:"-"`
+	FailedAttempts int        `json:"-"`
+	LockedUntil    *time.Time `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// RecoveryCode is a single-use fallback for a lost authenticator. Only the
+// hash is stored.
+type RecoveryCode struct {
+	ID        int        `json:"id" gorm:"primaryKey"`
+	UserID    int        `json:"user_id" gorm:"index"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFAChallenge is a login waiting for its second factor, identified by the
+// jti of the challenge token. It is deleted when the login completes, so
+// every challenge token is accepted only once.
+type MFAChallenge struct {
+	ID        int       `json:"id" gorm:"primaryKey"`
+	JTI       string    `json:"-" gorm:"uniqueIndex"`
+	UserID    int       `json:"user_id" gorm:"index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,149 @@
+// Package export writes a user's data as a ZIP archive holding a JSON and a
+// CSV file for each kind of record.
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Section is one kind of record in an export. Records is a struct or a slice
+// of structs; the CSV columns are the fields' JSON names.
+type Section struct {
+	Name    string
+	Records any
+}
+
+// WriteZip writes <name>.json and <name>.csv for every section
+func WriteZip(w io.Writer, sections []Section) error {
+	archive := zip.NewWriter(w)
+
+	for _, section := range sections {
+		file, err := archive.Create(section.Name + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.Records); err != nil {
+			return fmt.Errorf("export %s: %w", section.Name, err)
+		}
+
+		file, err = archive.Create(section.Name + ".csv")
+		if err != nil {
+			return err
+		}
+		if err := writeCSV(file, section.Records); err != nil {
+			return fmt.Errorf("export %s: %w", section.Name, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeCSV(w io.Writer, records any) error {
+	value := reflect.ValueOf(records)
+	rows := make([]reflect.Value, 0)
+	if value.Kind() == reflect.Slice {
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, reflect.Indirect(value.Index(i)))
+		}
+	} else if value.IsValid() && !(value.Kind() == reflect.Pointer && value.IsNil()) {
+		rows = append(rows, reflect.Indirect(value))
+	}
+
+	elem := reflect.TypeOf(records)
+	for elem != nil && (elem.Kind() == reflect.Slice || elem.Kind() == reflect.Pointer) {
+		elem = elem.Elem()
+	}
+	if elem == nil || elem.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot write %T as csv", records)
+	}
+	fields := csvFields(elem)
+
+	out := csv.NewWriter(w)
+	header := make([]string, 0, len(fields))
+	for _, field := range fields {
+		header = append(header, field.name)
+	}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := make([]string, 0, len(fields))
+		for _, field := range fields {
+			cell, err := formatCell(row.FieldByIndex(field.index))
+			if err != nil {
+				return err
+			}
+			record = append(record, cell)
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFields lists the exported fields of t that are included in JSON
+func csvFields(t reflect.Type) []csvField {
+	var fields []csvField
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, csvField{name: name, index: field.Index})
+	}
+	return fields
+}
+
+func formatCell(value reflect.Value) (string, error) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	switch v := value.Interface().(type) {
+	case time.Time:
+		if v.IsZero() {
+			return "", nil
+		}
+		return v.UTC().Format(time.RFC3339), nil
+	case string:
+		return v, nil
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Struct:
+		if value.Kind() != reflect.Struct && value.IsNil() {
+			return "", nil
+		}
+		encoded, err := json.Marshal(value.Interface())
+		return string(encoded), err
+	default:
+		return fmt.Sprint(value.Interface()), nil
+	}
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/hasher"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) RequestExport(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	record, err := h.usecase.RequestExport(principal.UserID, clientInfo(c, ""))
+	if err != nil {
+		respondAccountError(c, "Could not start data export", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Your data export is being prepared", "success", nil, http.StatusAccepted, record)
+}
+
+func (h *Handler) ListExports(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	exports, err := h.usecase.ListExports(principal.UserID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch data exports", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Data exports fetched successfully", "success", nil, http.StatusOK, exports)
+}
+
+func (h *Handler) GetExport(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid export id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	record, err := h.usecase.GetExport(principal.UserID, id)
+	if err != nil {
+		respondAccountError(c, "Failed to fetch data export", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Data export fetched successfully", "success", nil, http.StatusOK, record)
+}
+
+func (h *Handler) DownloadExport(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid export id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	record, err := h.usecase.ExportFile(principal.UserID, id)
+	if err != nil {
+		respondAccountError(c, "Could not download data export", err)
+		return
+	}
+
+	c.FileAttachment(record.FilePath, fmt.Sprintf("finora-export-%d.zip", record.ID))
+}
+
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.DeleteAccount
+	// Accounts without a password may send no body at all
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondBindError(c, err)
+		return
+	}
+
+	at, err := h.usecase.RequestAccountDeletion(principal.UserID, principal.SessionID, req.Password, clientInfo(c, ""))
+	if err != nil {
+		respondAccountError(c, "Could not delete account", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Your account will be deleted at the scheduled time unless you cancel", "success", nil, http.StatusAccepted, gin.H{"deletion_scheduled_at": at})
+}
+
+func (h *Handler) CancelAccountDeletion(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	if err := h.usecase.CancelAccountDeletion(principal.UserID, clientInfo(c, "")); err != nil {
+		respondAccountError(c, "Could not cancel account deletion", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Account deletion cancelled", "success", nil, http.StatusOK, nil)
+}
+
+func respondAccountError(c *gin.Context, message string, err error) {
+	var rateErr *usecase.RateLimitError
+	switch {
+	case errors.As(err, &rateErr):
+		respondRateLimited(c, message, rateErr)
+	case errors.Is(err, usecase.ErrExportNotFound):
+		response.NewCommonResponse(c, message, "error", err, http.StatusNotFound, nil)
+	case errors.Is(err, usecase.ErrExportInProgress), errors.Is(err, usecase.ErrExportNotReady):
+		response.NewCommonResponse(c, message, "error", err, http.StatusConflict, nil)
+	case errors.Is(err, usecase.ErrExportExpired):
+		response.NewCommonResponse(c, message, "error", err, http.StatusGone, nil)
+	case errors.Is(err, usecase.ErrIncorrectPassword), errors.Is(err, usecase.ErrReauthRequired):
+		response.NewCommonResponse(c, message, "error", err, http.StatusForbidden, nil)
+	case errors.Is(err, hasher.ErrBusy):
+		respondBusy(c, message, err)
+	default:
+		response.NewCommonResponse(c, message, "error", err, http.StatusInternalServerError, nil)
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// pagination reads the limit and offset query parameters, falling back to the
+// defaults for missing or invalid values.
+func pagination(c *gin.Context) (limit, offset int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	offset, err = strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (h *Handler) LoginThrottles(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	throttles, err := h.usecase.LoginThrottles(limit, offset)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch login throttles", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Login throttles fetched successfully", "success", nil, http.StatusOK, throttles)
+}
+
+func (h *Handler) SetUserRole(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid user id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	var req inbound.SetRole
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	principal, _ := middleware.CurrentUser(c)
+	if err := h.usecase.SetUserRole(principal.UserID, userID, req.Role, clientInfo(c, "")); err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrUserNotFound):
+			response.NewCommonResponse(c, "User not found", "error", err, http.StatusNotFound, nil)
+		case errors.Is(err, usecase.ErrInvalidRole):
+			response.NewCommonResponse(c, "Invalid role", "error", err, http.StatusBadRequest, nil)
+		case errors.Is(err, usecase.ErrLastAdmin):
+			response.NewCommonResponse(c, "Cannot change role", "error", err, http.StatusConflict, nil)
+		default:
+			response.NewCommonResponse(c, "Failed to update role", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	response.NewCommonResponse(c, "Role updated successfully", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) AdminSearchUsers(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	page, err := h.usecase.AdminSearchUsers(c.Query("q"), limit, offset)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch users", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Users fetched successfully", "success", nil, http.StatusOK, page)
+}
+
+func (h *Handler) AdminGetUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid user id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	user, err := h.usecase.AdminGetUser(userID)
+	if err != nil {
+		respondAdminError(c, "Failed to fetch user", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "User fetched successfully", "success", nil, http.StatusOK, user)
+}
+
+func (h *Handler) AdminVerifyUser(c *gin.Context) {
+	h.adminUserAction(c, h.usecase.AdminVerifyUser, "User verified successfully")
+}
+
+func (h *Handler) AdminDisableUser(c *gin.Context) {
+	h.adminUserAction(c, h.usecase.AdminDisableUser, "User disabled successfully")
+}
+
+func (h *Handler) AdminEnableUser(c *gin.Context) {
+	h.adminUserAction(c, h.usecase.AdminEnableUser, "User enabled successfully")
+}
+
+func (h *Handler) AdminResetTwoFactor(c *gin.Context) {
+	h.adminUserAction(c, h.usecase.AdminResetTwoFactor, "Two-factor authentication reset successfully")
+}
+
+func (h *Handler) AdminRevokeSessions(c *gin.Context) {
+	h.adminUserAction(c, h.usecase.AdminRevokeSessions, "Sessions revoked successfully")
+}
+
+func (h *Handler) AdminResendVerification(c *gin.Context) {
+	h.adminUserAction(c, h.usecase.AdminResendVerification, "Verification mail sent successfully")
+}
+
+// adminUserAction runs an admin action against the user named in the :id
+// path parameter on behalf of the signed-in admin.
+func (h *Handler) adminUserAction(c *gin.Context, action func(actorID, userID int, client inbound.Client) error, message string) {
+	principal, _ := middleware.CurrentUser(c)
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid user id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := action(principal.UserID, userID, clientInfo(c, "")); err != nil {
+		respondAdminError(c, "Action failed", err)
+		return
+	}
+
+	response.NewCommonResponse(c, message, "success", nil, http.StatusOK, nil)
+}
+
+func respondAdminError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrUserNotFound):
+		response.NewCommonResponse(c, message, "error", err, http.StatusNotFound, nil)
+	case errors.Is(err, usecase.ErrActOnSelf), errors.Is(err, usecase.ErrOtpUndeliverable):
+		response.NewCommonResponse(c, message, "error", err, http.StatusBadRequest, nil)
+	case errors.Is(err, usecase.ErrAlreadyVerified), errors.Is(err, usecase.ErrTwoFactorNotEnrolled), errors.Is(err, usecase.ErrAccountDeleted):
+		response.NewCommonResponse(c, message, "error", err, http.StatusConflict, nil)
+	default:
+		response.NewCommonResponse(c, message, "error", err, http.StatusInternalServerError, nil)
+	}
+}
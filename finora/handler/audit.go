@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) AuditEvents(c *gin.Context) {
+	var query inbound.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.NewCommonResponse(c, "Invalid filter", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+	limit, offset := pagination(c)
+
+	page, err := h.usecase.AuditEvents(query, limit, offset)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch audit events", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Audit events fetched successfully", "success", nil, http.StatusOK, page)
+}
+
+func (h *Handler) SecurityEvents(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+	limit, offset := pagination(c)
+
+	page, err := h.usecase.SecurityEvents(principal.UserID, limit, offset)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch security events", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Security events fetched successfully", "success", nil, http.StatusOK, page)
+}
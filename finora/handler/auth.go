@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
-	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/hasher"
 	"github.com/ayyoob-k-a/finora/model/inbound"
 	"github.com/ayyoob-k-a/finora/model/response"
 	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/ayyoob-k-a/finora/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,22 +25,33 @@ func NewHandler(usecase *usecase.Usecase) *Handler {
 //if user already signed up return string
 
 func (h *Handler) Signup(ctx *gin.Context) {
-	var signupData domain.User
+	var signupData inbound.Signup
 	if err := ctx.ShouldBindJSON(&signupData); err != nil {
-		ctx.JSON(400, gin.H{"error": "Invalid input"})
+		respondBindError(ctx, err)
 		return
 	}
 
-	err := h.usecase.Signup(signupData)
+	err := h.usecase.Signup(signupData, clientInfo(ctx, ""))
 	if err != nil {
+		if respondPasswordPolicy(ctx, "password", err) {
+			return
+		}
+		if errors.Is(err, hasher.ErrBusy) {
+			respondBusy(ctx, "Failed to sign up", err)
+			return
+		}
+		if errors.Is(err, usecase.ErrUnsupportedLocale) {
+			response.NewCommonResponse(ctx, "Failed to sign up", "error", err, http.StatusBadRequest, nil)
+			return
+		}
 		if err.Error() == "user already exists" {
-			ctx.JSON(409, gin.H{"error": "User already signed up"})
+			response.NewCommonResponse(ctx, "User already signed up", "error", err, http.StatusConflict, nil)
 			return
 		}
-		ctx.JSON(500, gin.H{"error": "Failed to sign up"})
+		response.NewCommonResponse(ctx, "Failed to sign up", "error", err, http.StatusInternalServerError, nil)
 		return
 	}
-	ctx.JSON(200, gin.H{"message": "User signed up successfully"})
+	response.NewCommonResponse(ctx, "User signed up successfully, check your email for the verification code", "success", nil, http.StatusOK, nil)
 }
 
 func (h *Handler) Login(c *gin.Context) {
@@ -49,13 +62,82 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	res, err := h.usecase.Login(req)
+	res, challenge, err := h.usecase.Login(req, clientInfo(c, req.Device))
 	if err != nil {
+		var rateErr *usecase.RateLimitError
+		if errors.As(err, &rateErr) {
+			respondRateLimited(c, "Login failed", rateErr)
+			return
+		}
+		if errors.Is(err, usecase.ErrAccountDisabled) {
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusForbidden, nil)
+			return
+		}
+		if errors.Is(err, hasher.ErrBusy) {
+			respondBusy(c, "Login failed", err)
+			return
+		}
+		if errors.Is(err, usecase.ErrVerificationPending) {
+			response.NewCommonResponse(c, "Login failed", "verification_pending", err, http.StatusForbidden, gin.H{
+				"verification_pending": true,
+				"resend_otp_url":       "/auth/resend-otp",
+			})
+			return
+		}
 		// c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		response.NewCommonResponse(c, "Login failed", "error", err, http.StatusUnauthorized, nil)
 		return
 	}
+	if challenge != nil {
+		response.NewCommonResponse(c, "Two-factor authentication required", "mfa_required", nil, http.StatusOK, challenge)
+		return
+	}
 
 	// c.JSON(http.StatusOK, res)
 	response.NewCommonResponse(c, "Login successful", "success", nil, http.StatusOK, res)
 }
+
+func (h *Handler) Refresh(c *gin.Context) {
+	var req inbound.Refresh
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	res, err := h.usecase.Refresh(req, clientInfo(c, ""))
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidToken) || errors.Is(err, usecase.ErrRefreshTokenReused) {
+			response.NewCommonResponse(c, "Refresh failed", "error", err, http.StatusUnauthorized, nil)
+			return
+		}
+		if errors.Is(err, usecase.ErrAccountDisabled) {
+			response.NewCommonResponse(c, "Refresh failed", "error", err, http.StatusForbidden, nil)
+			return
+		}
+		response.NewCommonResponse(c, "Refresh failed", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Token refreshed successfully", "success", nil, http.StatusOK, res)
+}
+
+func (h *Handler) UnlockAccount(c *gin.Context) {
+	err := h.usecase.UnlockAccount(c.Query("token"), clientInfo(c, ""))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidUnlockToken) {
+			response.NewCommonResponse(c, "Unlock failed", "error", err, http.StatusBadRequest, nil)
+			return
+		}
+		response.NewCommonResponse(c, "Unlock failed", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Account unlocked, you can log in again", "success", nil, http.StatusOK, nil)
+}
+
+// respondBusy asks the client to retry when every password hashing worker is
+// taken.
+func respondBusy(c *gin.Context, message string, err error) {
+	c.Header("Retry-After", "1")
+	response.NewCommonResponse(c, message, "error", err, http.StatusServiceUnavailable, nil)
+}
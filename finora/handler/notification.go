@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ListNotifications(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var query inbound.NotificationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.NewCommonResponse(c, "Invalid filter", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+	limit, _ := pagination(c)
+
+	page, err := h.usecase.ListNotifications(principal.UserID, query, limit)
+	if err != nil {
+		respondNotificationError(c, "Failed to fetch notifications", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Notifications fetched successfully", "success", nil, http.StatusOK, page)
+}
+
+func (h *Handler) UnreadNotificationCount(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	count, err := h.usecase.UnreadNotificationCount(principal.UserID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to count notifications", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Unread notifications counted", "success", nil, http.StatusOK, count)
+}
+
+func (h *Handler) MarkNotificationRead(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid notification id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.usecase.MarkNotificationRead(principal.UserID, id); err != nil {
+		respondNotificationError(c, "Failed to mark notification read", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Notification marked read", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) MarkAllNotificationsRead(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	marked, err := h.usecase.MarkAllNotificationsRead(principal.UserID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to mark notifications read", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "All notifications marked read", "success", nil, http.StatusOK, gin.H{"marked": marked})
+}
+
+func (h *Handler) DeleteNotification(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid notification id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.usecase.DeleteNotification(principal.UserID, id); err != nil {
+		respondNotificationError(c, "Failed to delete notification", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Notification deleted", "success", nil, http.StatusOK, nil)
+}
+
+func respondNotificationError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCursor), errors.Is(err, usecase.ErrUnknownNotificationType):
+		response.NewCommonResponse(c, message, "error", err, http.StatusBadRequest, nil)
+	case errors.Is(err, usecase.ErrNotificationNotFound):
+		response.NewCommonResponse(c, message, "error", err, http.StatusNotFound, nil)
+	default:
+		response.NewCommonResponse(c, message, "error", err, http.StatusInternalServerError, nil)
+	}
+}
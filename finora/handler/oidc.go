@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/oidc"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) OIDCProviders(c *gin.Context) {
+	response.NewCommonResponse(c, "Providers fetched successfully", "success", nil, http.StatusOK, h.usecase.OIDCProviders())
+}
+
+// oauthStateCookie binds an authorization request to the browser that started
+// it. It must survive the top-level redirect back from the provider, hence
+// SameSite=Lax.
+const oauthStateCookie = "finora_oauth_state"
+
+func setOAuthStateCookie(c *gin.Context, state string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthStateCookie, state, maxAge, "/auth/oidc", "", true, true)
+}
+
+// OIDCLogin redirects the browser to the provider's consent page
+func (h *Handler) OIDCLogin(c *gin.Context) {
+	authURL, state, err := h.usecase.StartOIDC(c.Request.Context(), c.Param("provider"), 0)
+	if err != nil {
+		respondOIDCError(c, "Could not start login", err)
+		return
+	}
+
+	setOAuthStateCookie(c, state, int(usecase.OAuthStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+func (h *Handler) OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		response.NewCommonResponse(c, "Login cancelled", "error", errors.New(providerErr), http.StatusBadRequest, nil)
+		return
+	}
+
+	browserState, _ := c.Cookie(oauthStateCookie)
+	setOAuthStateCookie(c, "", -1)
+
+	res, err := h.usecase.OIDCCallback(c.Request.Context(), c.Param("provider"), c.Query("code"), c.Query("state"), browserState, clientInfo(c, ""))
+	if err != nil {
+		respondOIDCError(c, "Login failed", err)
+		return
+	}
+
+	switch {
+	case res.LinkedIdentity != nil:
+		response.NewCommonResponse(c, "Provider linked successfully", "success", nil, http.StatusOK, res.LinkedIdentity)
+	case res.MFAChallenge != nil:
+		response.NewCommonResponse(c, "Two-factor authentication required", "mfa_required", nil, http.StatusOK, res.MFAChallenge)
+	default:
+		response.NewCommonResponse(c, "Login successful", "success", nil, http.StatusOK, res.Auth)
+	}
+}
+
+func (h *Handler) ListIdentities(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	identities, err := h.usecase.ListIdentities(principal.UserID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch linked providers", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Linked providers fetched successfully", "success", nil, http.StatusOK, identities)
+}
+
+// LinkIdentity returns the provider URL instead of redirecting, since the
+// request carries a bearer token that a browser navigation could not. The
+// state cookie is still set, so the URL must be opened in the same browser.
+func (h *Handler) LinkIdentity(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	authURL, state, err := h.usecase.StartOIDC(c.Request.Context(), c.Param("provider"), principal.UserID)
+	if err != nil {
+		respondOIDCError(c, "Could not start linking", err)
+		return
+	}
+
+	setOAuthStateCookie(c, state, int(usecase.OAuthStateTTL.Seconds()))
+	response.NewCommonResponse(c, "Open the authorization URL to link the provider", "success", nil, http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	if err := h.usecase.UnlinkIdentity(principal.UserID, c.Param("provider"), clientInfo(c, "")); err != nil {
+		respondOIDCError(c, "Could not unlink provider", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Provider unlinked successfully", "success", nil, http.StatusOK, nil)
+}
+
+func respondOIDCError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, oidc.ErrUnknownProvider), errors.Is(err, usecase.ErrIdentityNotLinked):
+		response.NewCommonResponse(c, message, "error", err, http.StatusNotFound, nil)
+	case errors.Is(err, usecase.ErrInvalidOAuthState), errors.Is(err, oidc.ErrInvalidIDToken), errors.Is(err, usecase.ErrOIDCEmailNotVerified):
+		response.NewCommonResponse(c, message, "error", err, http.StatusUnauthorized, nil)
+	case errors.Is(err, usecase.ErrIdentityLinkedElsewhere), errors.Is(err, usecase.ErrLastSignInMethod):
+		response.NewCommonResponse(c, message, "error", err, http.StatusConflict, nil)
+	case errors.Is(err, usecase.ErrAccountDisabled):
+		response.NewCommonResponse(c, message, "error", err, http.StatusForbidden, nil)
+	default:
+		response.NewCommonResponse(c, message, "error", err, http.StatusInternalServerError, nil)
+	}
+}
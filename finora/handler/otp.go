@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) VerifyOTP(c *gin.Context) {
+	var req inbound.VerifyOtp
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	err := h.usecase.VerifyOTP(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrInvalidOtp), errors.Is(err, usecase.ErrOtpExpired):
+			response.NewCommonResponse(c, "Verification failed", "error", err, http.StatusBadRequest, nil)
+		case errors.Is(err, usecase.ErrOtpAttemptsExceeded):
+			response.NewCommonResponse(c, "Verification failed", "error", err, http.StatusTooManyRequests, nil)
+		default:
+			response.NewCommonResponse(c, "Verification failed", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	response.NewCommonResponse(c, "User verified successfully", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) ResendOTP(c *gin.Context) {
+	var req inbound.ResendOtp
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	err := h.usecase.ResendOTP(req, c.ClientIP())
+	if err != nil {
+		var rateErr *usecase.RateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			respondRateLimited(c, "Could not resend OTP", rateErr)
+		default:
+			response.NewCommonResponse(c, "Could not resend OTP", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	response.NewCommonResponse(c, "If the account exists, a new OTP has been sent", "success", nil, http.StatusOK, nil)
+}
+
+// respondRateLimited writes a 429 with a Retry-After header and the same hint
+// in the response body.
+func respondRateLimited(c *gin.Context, message string, err *usecase.RateLimitError) {
+	retryAfter := err.RetryAfterSeconds()
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	response.NewCommonResponse(c, message, "error", err, http.StatusTooManyRequests, gin.H{"retry_after": retryAfter})
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidEmailStatus = errors.New("status must be pending, sent or dead")
+
+// OutboxEmails lists queued email, filtered by the status query parameter
+func (h *Handler) OutboxEmails(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", domain.EmailPending, domain.EmailSent, domain.EmailDead:
+	default:
+		response.NewCommonResponse(c, "Invalid filter", "error", errInvalidEmailStatus, http.StatusBadRequest, nil)
+		return
+	}
+	limit, offset := pagination(c)
+
+	page, err := h.usecase.OutboxEmails(status, limit, offset)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch emails", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Emails fetched successfully", "success", nil, http.StatusOK, page)
+}
+
+func (h *Handler) EmailTemplates(c *gin.Context) {
+	response.NewCommonResponse(c, "Email templates fetched successfully", "success", nil, http.StatusOK, h.usecase.EmailTemplates())
+}
+
+// PreviewEmailTemplate renders a template with sample data. With
+// format=html or format=text the part is returned as is, for viewing in a
+// browser; otherwise both parts come back as JSON.
+func (h *Handler) PreviewEmailTemplate(c *gin.Context) {
+	preview, err := h.usecase.PreviewEmailTemplate(c.Param("name"), c.Query("locale"))
+	if err != nil {
+		switch {
+		case errors.Is(err, mailer.ErrUnknownTemplate):
+			response.NewCommonResponse(c, "Template not found", "error", err, http.StatusNotFound, nil)
+		case errors.Is(err, mailer.ErrUnknownLocale):
+			response.NewCommonResponse(c, "Invalid locale", "error", err, http.StatusBadRequest, nil)
+		default:
+			response.NewCommonResponse(c, "Failed to render template", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	switch c.Query("format") {
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(preview.HTML))
+	case "text":
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(preview.Text))
+	default:
+		response.NewCommonResponse(c, "Template rendered successfully", "success", nil, http.StatusOK, preview)
+	}
+}
+
+func (h *Handler) RetryEmail(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid email id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.usecase.RetryEmail(principal.UserID, id, clientInfo(c, "")); err != nil {
+		if errors.Is(err, usecase.ErrEmailNotDead) {
+			response.NewCommonResponse(c, "Email not found", "error", err, http.StatusNotFound, nil)
+			return
+		}
+		if errors.Is(err, usecase.ErrEmailExpired) {
+			response.NewCommonResponse(c, "Email cannot be retried", "error", err, http.StatusGone, nil)
+			return
+		}
+		response.NewCommonResponse(c, "Failed to retry email", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Email queued for another attempt", "success", nil, http.StatusOK, nil)
+}
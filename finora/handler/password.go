@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/hasher"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req inbound.ForgotPassword
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.usecase.ForgotPassword(req, c.ClientIP()); err != nil {
+		response.NewCommonResponse(c, "Could not process request", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "If the account exists, password reset instructions have been sent", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req inbound.ResetPassword
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	err := h.usecase.ResetPassword(req, clientInfo(c, ""))
+	if err != nil {
+		if respondPasswordPolicy(c, "new_password", err) {
+			return
+		}
+		switch {
+		case errors.Is(err, usecase.ErrInvalidResetToken), errors.Is(err, usecase.ErrResetProofRequired),
+			errors.Is(err, usecase.ErrInvalidOtp), errors.Is(err, usecase.ErrOtpExpired):
+			response.NewCommonResponse(c, "Password reset failed", "error", err, http.StatusBadRequest, nil)
+		case errors.Is(err, usecase.ErrOtpAttemptsExceeded):
+			response.NewCommonResponse(c, "Password reset failed", "error", err, http.StatusTooManyRequests, nil)
+		case errors.Is(err, hasher.ErrBusy):
+			respondBusy(c, "Password reset failed", err)
+		default:
+			response.NewCommonResponse(c, "Password reset failed", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	response.NewCommonResponse(c, "Password reset successfully", "success", nil, http.StatusOK, nil)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) SendLoginOTP(c *gin.Context) {
+	var req inbound.SendOtp
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	err := h.usecase.SendLoginOTP(req, c.ClientIP())
+	if err != nil {
+		var rateErr *usecase.RateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			respondRateLimited(c, "Could not send OTP", rateErr)
+		case errors.Is(err, usecase.ErrInvalidIdentifier):
+			response.NewCommonResponse(c, "Could not send OTP", "error", err, http.StatusBadRequest, nil)
+		case errors.Is(err, usecase.ErrSMSFailed):
+			response.NewCommonResponse(c, "Could not send OTP", "error", err, http.StatusServiceUnavailable, nil)
+		default:
+			response.NewCommonResponse(c, "Could not send OTP", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+
+	response.NewCommonResponse(c, "OTP sent successfully", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) VerifyLoginOTP(c *gin.Context) {
+	var req inbound.VerifyLoginOtp
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	res, challenge, err := h.usecase.VerifyLoginOTP(req, clientInfo(c, req.Device))
+	if err != nil {
+		var rateErr *usecase.RateLimitError
+		switch {
+		case errors.As(err, &rateErr):
+			respondRateLimited(c, "Login failed", rateErr)
+		case errors.Is(err, usecase.ErrInvalidIdentifier):
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusBadRequest, nil)
+		case errors.Is(err, usecase.ErrInvalidOtp), errors.Is(err, usecase.ErrOtpExpired):
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusUnauthorized, nil)
+		case errors.Is(err, usecase.ErrOtpAttemptsExceeded):
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusTooManyRequests, nil)
+		case errors.Is(err, usecase.ErrAccountDisabled):
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusForbidden, nil)
+		default:
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusInternalServerError, nil)
+		}
+		return
+	}
+	if challenge != nil {
+		response.NewCommonResponse(c, "Two-factor authentication required", "mfa_required", nil, http.StatusOK, challenge)
+		return
+	}
+
+	response.NewCommonResponse(c, "Login successful", "success", nil, http.StatusOK, res)
+}
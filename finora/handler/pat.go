@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) CreatePAT(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.CreatePAT
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	token, err := h.usecase.CreatePAT(principal.UserID, req, clientInfo(c, ""))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidScope) {
+			response.NewCommonResponse(c, "Invalid scope", "error", err, http.StatusBadRequest, nil)
+			return
+		}
+		response.NewCommonResponse(c, "Failed to create access token", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Access token created, copy it now as it will not be shown again", "success", nil, http.StatusCreated, token)
+}
+
+func (h *Handler) ListPATs(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	tokens, err := h.usecase.ListPATs(principal.UserID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch access tokens", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Access tokens fetched successfully", "success", nil, http.StatusOK, tokens)
+}
+
+func (h *Handler) RevokePAT(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid access token id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.usecase.RevokePAT(principal.UserID, id, clientInfo(c, "")); err != nil {
+		if errors.Is(err, usecase.ErrPATNotFound) {
+			response.NewCommonResponse(c, "Access token not found", "error", err, http.StatusNotFound, nil)
+			return
+		}
+		response.NewCommonResponse(c, "Failed to revoke access token", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Access token revoked", "success", nil, http.StatusOK, nil)
+}
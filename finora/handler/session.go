@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// clientInfo collects the request metadata recorded on a session. An explicit
+// device name from the request body wins over the X-Device-Name header.
+func clientInfo(c *gin.Context, device string) inbound.Client {
+	if device == "" {
+		device = c.GetHeader("X-Device-Name")
+	}
+
+	return inbound.Client{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Device:    device,
+	}
+}
+
+func (h *Handler) ListSessions(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	sessions, err := h.usecase.ListSessions(principal.UserID, principal.SessionID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch sessions", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Sessions fetched successfully", "success", nil, http.StatusOK, sessions)
+}
+
+func (h *Handler) Logout(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	if err := h.usecase.RevokeSession(principal.UserID, principal.SessionID, principal.SessionID, clientInfo(c, "")); err != nil {
+		response.NewCommonResponse(c, "Logout failed", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Logged out successfully", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) RevokeSession(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	sessionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.NewCommonResponse(c, "Invalid session id", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	err = h.usecase.RevokeSession(principal.UserID, sessionID, principal.SessionID, clientInfo(c, ""))
+	if err != nil {
+		if errors.Is(err, usecase.ErrSessionNotFound) {
+			response.NewCommonResponse(c, "Failed to revoke session", "error", err, http.StatusNotFound, nil)
+			return
+		}
+		response.NewCommonResponse(c, "Failed to revoke session", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Session revoked successfully", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) LogoutAll(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	if err := h.usecase.LogoutAll(principal.UserID, clientInfo(c, "")); err != nil {
+		response.NewCommonResponse(c, "Failed to log out of all sessions", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "Logged out of all sessions", "success", nil, http.StatusOK, nil)
+}
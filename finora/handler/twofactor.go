@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) EnrollTwoFactor(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	res, err := h.usecase.EnrollTwoFactor(principal.UserID)
+	if err != nil {
+		respondTwoFactorError(c, "Two-factor enrollment failed", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Scan the URI with your authenticator app and confirm with a code", "success", nil, http.StatusOK, res)
+}
+
+func (h *Handler) ConfirmTwoFactor(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.TwoFactorCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	res, err := h.usecase.ConfirmTwoFactor(principal.UserID, req.Code, clientInfo(c, ""))
+	if err != nil {
+		respondTwoFactorError(c, "Two-factor confirmation failed", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Two-factor authentication enabled. Store these recovery codes safely, they are shown only once", "success", nil, http.StatusOK, res)
+}
+
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.TwoFactorCode
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	if err := h.usecase.DisableTwoFactor(principal.UserID, req.Code, clientInfo(c, "")); err != nil {
+		respondTwoFactorError(c, "Could not disable two-factor authentication", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Two-factor authentication disabled", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) VerifyMFA(c *gin.Context) {
+	var req inbound.VerifyMFA
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	res, err := h.usecase.VerifyMFA(req, clientInfo(c, req.Device))
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidMFAChallenge) {
+			response.NewCommonResponse(c, "Login failed", "error", err, http.StatusUnauthorized, nil)
+			return
+		}
+		respondTwoFactorError(c, "Login failed", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Login successful", "success", nil, http.StatusOK, res)
+}
+
+func respondTwoFactorError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidTwoFactorCode):
+		response.NewCommonResponse(c, message, "error", err, http.StatusUnauthorized, nil)
+	case errors.Is(err, usecase.ErrTwoFactorLocked):
+		response.NewCommonResponse(c, message, "error", err, http.StatusTooManyRequests, nil)
+	case errors.Is(err, usecase.ErrTwoFactorAlreadyEnabled), errors.Is(err, usecase.ErrTwoFactorNotEnrolled):
+		response.NewCommonResponse(c, message, "error", err, http.StatusConflict, nil)
+	case errors.Is(err, usecase.ErrAccountDisabled):
+		response.NewCommonResponse(c, message, "error", err, http.StatusForbidden, nil)
+	default:
+		response.NewCommonResponse(c, message, "error", err, http.StatusInternalServerError, nil)
+	}
+}
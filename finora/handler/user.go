@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/hasher"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handler) Me(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	profile, err := h.usecase.GetProfile(principal.UserID)
+	if err != nil {
+		response.NewCommonResponse(c, "Failed to fetch user", "error", err, http.StatusInternalServerError, nil)
+		return
+	}
+
+	response.NewCommonResponse(c, "User fetched successfully", "success", nil, http.StatusOK, profile)
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.UpdateProfile
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	res, err := h.usecase.UpdateProfile(principal.UserID, principal.SessionID, req, clientInfo(c, ""))
+	if err != nil {
+		respondProfileError(c, "Failed to update profile", err)
+		return
+	}
+
+	message := "Profile updated successfully"
+	if len(res.VerificationSentTo) > 0 {
+		message = "Profile updated, confirm the code sent to your new contact details to finish the change"
+	}
+	response.NewCommonResponse(c, message, "success", nil, http.StatusOK, res)
+}
+
+func (h *Handler) ConfirmContactChange(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.ConfirmContactChange
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	profile, err := h.usecase.ConfirmContactChange(principal.UserID, req, clientInfo(c, ""))
+	if err != nil {
+		respondProfileError(c, "Failed to confirm change", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Contact details updated successfully", "success", nil, http.StatusOK, profile)
+}
+
+func (h *Handler) ChangePassword(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.ChangePassword
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	err := h.usecase.ChangePassword(principal.UserID, principal.SessionID, req, clientInfo(c, ""))
+	if err != nil {
+		if respondPasswordPolicy(c, "new_password", err) {
+			return
+		}
+		respondProfileError(c, "Failed to change password", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "Password changed, other sessions have been signed out", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) SendReauthOTP(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	if err := h.usecase.SendReauthOTP(principal.UserID, c.ClientIP()); err != nil {
+		respondProfileError(c, "Could not send code", err)
+		return
+	}
+
+	response.NewCommonResponse(c, "A code has been sent to your account's contact details", "success", nil, http.StatusOK, nil)
+}
+
+func (h *Handler) Reauthenticate(c *gin.Context) {
+	principal, _ := middleware.CurrentUser(c)
+
+	var req inbound.Reauthenticate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	err := h.usecase.Reauthenticate(principal.UserID, principal.SessionID, req, clientInfo(c, ""))
+	if err != nil {
+		switch {
+		case errors.Is(err, usecase.ErrReauthProofRequired):
+			response.NewCommonResponse(c, "Re-authentication failed", "error", err, http.StatusBadRequest, nil)
+		case errors.Is(err, usecase.ErrInvalidTwoFactorCode), errors.Is(err, usecase.ErrTwoFactorLocked), errors.Is(err, usecase.ErrTwoFactorNotEnrolled):
+			respondTwoFactorError(c, "Re-authentication failed", err)
+		default:
+			respondProfileError(c, "Re-authentication failed", err)
+		}
+		return
+	}
+
+	response.NewCommonResponse(c, "Identity confirmed", "success", nil, http.StatusOK, nil)
+}
+
+func respondProfileError(c *gin.Context, message string, err error) {
+	var rateErr *usecase.RateLimitError
+	switch {
+	case errors.As(err, &rateErr):
+		respondRateLimited(c, message, rateErr)
+	case errors.Is(err, usecase.ErrNothingToUpdate), errors.Is(err, usecase.ErrOtpUndeliverable), errors.Is(err, usecase.ErrUnsupportedLocale),
+		errors.Is(err, usecase.ErrInvalidOtp), errors.Is(err, usecase.ErrOtpExpired):
+		response.NewCommonResponse(c, message, "error", err, http.StatusBadRequest, nil)
+	case errors.Is(err, usecase.ErrIncorrectPassword), errors.Is(err, usecase.ErrReauthRequired):
+		response.NewCommonResponse(c, message, "error", err, http.StatusForbidden, nil)
+	case errors.Is(err, usecase.ErrContactInUse):
+		response.NewCommonResponse(c, message, "error", err, http.StatusConflict, nil)
+	case errors.Is(err, usecase.ErrOtpAttemptsExceeded):
+		response.NewCommonResponse(c, message, "error", err, http.StatusTooManyRequests, nil)
+	case errors.Is(err, usecase.ErrSMSFailed):
+		response.NewCommonResponse(c, message, "error", err, http.StatusServiceUnavailable, nil)
+	case errors.Is(err, hasher.ErrBusy):
+		respondBusy(c, message, err)
+	default:
+		response.NewCommonResponse(c, message, "error", err, http.StatusInternalServerError, nil)
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/policy"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// usernamePattern allows 3 to 30 letters, digits, dots and underscores,
+// starting with a letter.
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._]{2,29}$`)
+
+// RegisterValidators adds the custom binding rules used by the inbound models
+// and makes validation errors report JSON field names.
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	return v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+}
+
+// respondBindError writes a 400 listing a message per invalid field. Errors
+// that are not validation errors, such as malformed JSON, are reported as is.
+func respondBindError(c *gin.Context, err error) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		response.NewCommonResponse(c, "Invalid input", "error", err, http.StatusBadRequest, nil)
+		return
+	}
+
+	fields := make(map[string]string, len(validationErrs))
+	for _, fe := range validationErrs {
+		fields[fe.Field()] = fieldErrorMessage(fe)
+	}
+
+	response.NewCommonResponse(c, "Invalid input", "error", errors.New("validation failed"), http.StatusBadRequest, fields)
+}
+
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "e164":
+		return "must be a phone number in international format, e.g. +14155552671"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters", fe.Param())
+	case "len":
+		return fmt.Sprintf("must be exactly %s characters", fe.Param())
+	case "numeric":
+		return "must contain only digits"
+	case "eqfield":
+		return "does not match password"
+	case "username":
+		return "must be 3-30 characters of letters, digits, dots or underscores and start with a letter"
+	default:
+		return "is invalid"
+	}
+}
+
+// respondPasswordPolicy writes a 400 listing every password rule that field
+// failed. It reports false if err is not a policy violation.
+func respondPasswordPolicy(c *gin.Context, field string, err error) bool {
+	var violationErr *policy.ViolationError
+	if !errors.As(err, &violationErr) {
+		return false
+	}
+
+	response.NewCommonResponse(c, "Password does not meet the policy", "error", err, http.StatusBadRequest, gin.H{
+		field: violationErr.Violations,
+	})
+	return true
+}
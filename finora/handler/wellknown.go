@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/ayyoob-k-a/finora/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS publishes the token verification keys. It is served in the standard
+// JWK Set format rather than the usual response envelope so other services
+// can consume it with off-the-shelf JWT libraries.
+func (h *Handler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.PublicJWKS())
+}
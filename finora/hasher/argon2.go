@@ -0,0 +1,95 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2Prefix = "$argon2id$"
+
+// MaxArgon2Memory is the most memory, in KiB, a stored hash may ask for.
+// Hashes come from the database, so they are checked like any other input
+// before deciding how much work to do for them.
+const MaxArgon2Memory = 1 << 20
+
+// Argon2id hashes passwords with argon2id and encodes them in the PHC string
+// format: $argon2id$v=19$m=<KiB>,t=<iterations>,p=<parallelism>$<salt>$<key>
+type Argon2id struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix, argon2.Version, a.Memory, a.Iterations, a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *Argon2id) Verify(encoded, password string) (bool, error) {
+	params, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (a *Argon2id) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != a.Memory ||
+		params.Iterations != a.Iterations ||
+		params.Parallelism != a.Parallelism ||
+		uint32(len(salt)) != a.SaltLength ||
+		uint32(len(key)) != a.KeyLength
+}
+
+func (a *Argon2id) matches(encoded string) bool {
+	return strings.HasPrefix(encoded, argon2Prefix)
+}
+
+func decodeArgon2(encoded string) (params Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if params.Iterations < 1 || params.Parallelism < 1 || params.Memory > MaxArgon2Memory {
+		return params, nil, nil, ErrUnknownHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, ErrUnknownHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return params, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
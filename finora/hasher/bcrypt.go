@@ -0,0 +1,38 @@
+package hasher
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Bcrypt hashes passwords with bcrypt. It is kept so that accounts created
+// before argon2id became the default can still sign in.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(hashed), err
+}
+
+func (b *Bcrypt) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *Bcrypt) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != b.Cost
+}
+
+func (b *Bcrypt) matches(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
@@ -0,0 +1,167 @@
+// Package hasher hashes and verifies user passwords. Hashes are stored in a
+// self-describing format, so hashes made by an older algorithm or with older
+// parameters keep verifying and can be upgraded on the next login.
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrBusy is returned when no hashing worker became free in time
+	ErrBusy = errors.New("server is busy, try again shortly")
+	// ErrUnknownHash is returned for hashes no configured algorithm produced
+	ErrUnknownHash = errors.New("unrecognised password hash")
+)
+
+// Hasher hashes passwords and verifies them against stored hashes
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	// NeedsRehash reports whether encoded should be replaced by a fresh hash
+	// made with the current algorithm and parameters.
+	NeedsRehash(encoded string) bool
+}
+
+// scheme is a single algorithm that can recognise its own hashes
+type scheme interface {
+	Hasher
+	matches(encoded string) bool
+}
+
+// New builds the hasher described by cfg. New hashes use cfg.Algorithm;
+// hashes from every supported algorithm are still verified.
+func New(cfg configs.Password) (Hasher, error) {
+	if cfg.Argon2Memory <= 0 || cfg.Argon2Iterations <= 0 || cfg.Argon2Parallelism <= 0 || cfg.Argon2Parallelism > 255 {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+	if cfg.Argon2Memory > MaxArgon2Memory {
+		return nil, fmt.Errorf("argon2id memory must be at most %d KiB", MaxArgon2Memory)
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	argonScheme := &Argon2id{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+	bcryptScheme := &Bcrypt{Cost: cfg.BcryptCost}
+
+	var primary scheme
+	switch cfg.Algorithm {
+	case "", "argon2id":
+		primary = argonScheme
+	case "bcrypt":
+		primary = bcryptScheme
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.Algorithm)
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &pool{
+		next: &multi{
+			primary: primary,
+			schemes: []scheme{argonScheme, bcryptScheme},
+		},
+		slots:   make(chan struct{}, workers),
+		timeout: cfg.QueueTimeout,
+	}, nil
+}
+
+// multi hashes with the primary scheme and verifies with whichever scheme
+// produced the stored hash.
+type multi struct {
+	primary scheme
+	schemes []scheme
+}
+
+func (m *multi) Hash(password string) (string, error) {
+	return m.primary.Hash(password)
+}
+
+func (m *multi) Verify(encoded, password string) (bool, error) {
+	for _, s := range m.schemes {
+		if s.matches(encoded) {
+			return s.Verify(encoded, password)
+		}
+	}
+	return false, ErrUnknownHash
+}
+
+func (m *multi) NeedsRehash(encoded string) bool {
+	if !m.primary.matches(encoded) {
+		return true
+	}
+	return m.primary.NeedsRehash(encoded)
+}
+
+// pool bounds how many hashes are computed at once. Callers wait up to
+// timeout for a free worker, so a burst of logins queues briefly and then
+// fails fast instead of exhausting CPU and memory.
+type pool struct {
+	next    Hasher
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+func (p *pool) Hash(password string) (string, error) {
+	if err := p.acquire(); err != nil {
+		return "", err
+	}
+	defer p.release()
+
+	return p.next.Hash(password)
+}
+
+func (p *pool) Verify(encoded, password string) (bool, error) {
+	if err := p.acquire(); err != nil {
+		return false, err
+	}
+	defer p.release()
+
+	return p.next.Verify(encoded, password)
+}
+
+func (p *pool) NeedsRehash(encoded string) bool {
+	return p.next.NeedsRehash(encoded)
+}
+
+func (p *pool) acquire() error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if p.timeout <= 0 {
+		p.slots <- struct{}{}
+		return nil
+	}
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBusy
+	}
+}
+
+func (p *pool) release() {
+	<-p.slots
+}
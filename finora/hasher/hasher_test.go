@@ -0,0 +1,150 @@
+package hasher
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testConfig uses the cheapest parameters each algorithm accepts so the
+// tests stay fast.
+func testConfig(algorithm string) configs.Password {
+	return configs.Password{
+		Algorithm:         algorithm,
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        bcrypt.MinCost,
+		Workers:           2,
+		QueueTimeout:      time.Second,
+	}
+}
+
+func newHasher(t *testing.T, cfg configs.Password) Hasher {
+	t.Helper()
+	h, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestBcryptHashIsUpgradedToArgon2id(t *testing.T) {
+	old := newHasher(t, testConfig("bcrypt"))
+	encoded, err := old.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$2") {
+		t.Fatalf("expected a bcrypt hash, got %q", encoded)
+	}
+
+	current := newHasher(t, testConfig("argon2id"))
+	ok, err := current.Verify(encoded, "correct horse")
+	if err != nil || !ok {
+		t.Fatalf("bcrypt hash no longer verifies: ok=%v err=%v", ok, err)
+	}
+	if !current.NeedsRehash(encoded) {
+		t.Fatal("bcrypt hash should need a rehash once argon2id is the algorithm")
+	}
+
+	upgraded, err := current.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(upgraded, argon2Prefix) {
+		t.Fatalf("expected an argon2id hash, got %q", upgraded)
+	}
+	if current.NeedsRehash(upgraded) {
+		t.Fatal("fresh argon2id hash should not need a rehash")
+	}
+	if ok, err := current.Verify(upgraded, "correct horse"); err != nil || !ok {
+		t.Fatalf("upgraded hash does not verify: ok=%v err=%v", ok, err)
+	}
+	if ok, _ := current.Verify(upgraded, "wrong horse"); ok {
+		t.Fatal("upgraded hash accepted a wrong password")
+	}
+}
+
+func TestNeedsRehashAfterParameterChange(t *testing.T) {
+	cfg := testConfig("argon2id")
+	encoded, err := newHasher(t, cfg).Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := map[string]func(*configs.Password){
+		"memory":      func(c *configs.Password) { c.Argon2Memory *= 2 },
+		"iterations":  func(c *configs.Password) { c.Argon2Iterations++ },
+		"parallelism": func(c *configs.Password) { c.Argon2Parallelism++ },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			next := cfg
+			change(&next)
+			h := newHasher(t, next)
+
+			if !h.NeedsRehash(encoded) {
+				t.Fatal("hash made with the old parameters should need a rehash")
+			}
+			if ok, err := h.Verify(encoded, "correct horse"); err != nil || !ok {
+				t.Fatalf("old hash no longer verifies: ok=%v err=%v", ok, err)
+			}
+		})
+	}
+}
+
+func TestPoolReturnsErrBusyWhenWorkersStayTaken(t *testing.T) {
+	cfg := testConfig("argon2id")
+	cfg.Workers = 1
+	cfg.QueueTimeout = 20 * time.Millisecond
+	h := newHasher(t, cfg)
+
+	p := h.(*pool)
+	if err := p.acquire(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if _, err := h.Hash("correct horse"); !errors.Is(err, ErrBusy) {
+		t.Fatalf("expected ErrBusy from Hash, got %v", err)
+	}
+	if waited := time.Since(start); waited < cfg.QueueTimeout {
+		t.Fatalf("gave up after %v, before the %v queue timeout", waited, cfg.QueueTimeout)
+	}
+	if _, err := h.Verify("$2a$04$invalid", "correct horse"); !errors.Is(err, ErrBusy) {
+		t.Fatalf("expected ErrBusy from Verify, got %v", err)
+	}
+
+	p.release()
+	if _, err := h.Hash("correct horse"); err != nil {
+		t.Fatalf("hash failed once a worker was free: %v", err)
+	}
+}
+
+func TestDecodeArgon2RejectsUnsafeParameters(t *testing.T) {
+	const salt, key = "c2FsdHNhbHRzYWx0c2FsdA", "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+	tests := map[string]string{
+		"zero iterations":  "m=64,t=0,p=1",
+		"zero parallelism": "m=64,t=1,p=0",
+		"huge memory":      fmt.Sprintf("m=%d,t=1,p=1", MaxArgon2Memory+1),
+	}
+
+	h := newHasher(t, testConfig("argon2id"))
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			encoded := fmt.Sprintf("$argon2id$v=19$%s$%s$%s", params, salt, key)
+			if _, err := h.Verify(encoded, "correct horse"); !errors.Is(err, ErrUnknownHash) {
+				t.Fatalf("expected ErrUnknownHash, got %v", err)
+			}
+			if !h.NeedsRehash(encoded) {
+				t.Fatal("a hash that cannot be decoded should need a rehash")
+			}
+		})
+	}
+}
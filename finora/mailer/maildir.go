@@ -0,0 +1,60 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Maildir writes every email to a maildir on disk instead of sending it, so
+// development setups can read mail with any maildir-aware client or just
+// open the files.
+type Maildir struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+func NewMaildir(dir, from string) (*Maildir, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("MAIL_DIR is required for the file mail backend")
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Maildir{dir: dir, from: from}, nil
+}
+
+// Send writes the message to tmp/ and moves it into new/ once complete, so a
+// reader never sees a partial file.
+func (m *Maildir) Send(msg Message) error {
+	message, err := build(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	host, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s", time.Now().UnixNano(), os.Getpid(), m.seq.Add(1), host)
+	tmpPath := filepath.Join(m.dir, "tmp", name)
+
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := message.WriteTo(file); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(m.dir, "new", name))
+}
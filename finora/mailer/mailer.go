@@ -0,0 +1,88 @@
+// Package mailer delivers outgoing email. The backend is chosen by
+// configuration: SMTP in production, a maildir on disk for development, or
+// an in-memory capture for tests.
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	gomail "gopkg.in/mail.v2"
+)
+
+// Mail backends
+const (
+	BackendSMTP   = "smtp"
+	BackendFile   = "file"
+	BackendMemory = "memory"
+)
+
+var ErrNoRecipient = errors.New("email has no recipient")
+
+// Message is one email. HTML and Text are alternative bodies; at least one
+// must be set. Template, Locale and Version record where a rendered message
+// came from.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+
+	Template string
+	Locale   string
+	Version  string
+}
+
+// Mailer sends email. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New returns the mailer selected by cfg.Backend
+func New(cfg configs.Mail) (Mailer, error) {
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid MAIL_FROM %q: %w", cfg.From, err)
+	}
+
+	switch cfg.Backend {
+	case BackendSMTP:
+		return NewSMTP(cfg)
+	case BackendFile:
+		return NewMaildir(cfg.Dir, cfg.From)
+	case BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_BACKEND %q", cfg.Backend)
+	}
+}
+
+// build turns msg into a MIME message from the given sender
+func build(from string, msg Message) (*gomail.Message, error) {
+	if strings.TrimSpace(msg.To) == "" {
+		return nil, ErrNoRecipient
+	}
+	if msg.HTML == "" && msg.Text == "" {
+		return nil, fmt.Errorf("email %q has no body", msg.Subject)
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", msg.To)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetDateHeader("Date", time.Now())
+
+	switch {
+	case msg.Text != "" && msg.HTML != "":
+		m.SetBody("text/plain", msg.Text)
+		m.AddAlternative("text/html", msg.HTML)
+	case msg.HTML != "":
+		m.SetBody("text/html", msg.HTML)
+	default:
+		m.SetBody("text/plain", msg.Text)
+	}
+	return m, nil
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import "sync"
+
+// Memory keeps sent messages in memory, for tests
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.err != nil {
+		return m.err
+	}
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of everything sent so far
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Last returns the most recent message sent to, if any
+func (m *Memory) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// SetError makes every later Send fail with err, or succeed again if err is nil
+func (m *Memory) SetError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.err = err
+}
+
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
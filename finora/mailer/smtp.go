@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	gomail "gopkg.in/mail.v2"
+)
+
+// SMTP TLS modes
+const (
+	TLSStartTLS = "starttls" // upgrade with STARTTLS, refusing servers without it
+	TLSImplicit = "tls"      // TLS from the first byte, usually port 465
+	TLSNone     = "none"     // plaintext, only for a local relay
+)
+
+const smtpTimeout = 10 * time.Second
+
+// SMTP sends mail through an SMTP server. Certificates are always verified.
+type SMTP struct {
+	dialer *gomail.Dialer
+	from   string
+}
+
+func NewSMTP(cfg configs.Mail) (*SMTP, error) {
+	if cfg.SMTPHost == "" {
+		return nil, errors.New("SMTP_HOST is required for the smtp mail backend")
+	}
+
+	dialer := gomail.NewDialer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword)
+	dialer.Timeout = smtpTimeout
+	dialer.TLSConfig = &tls.Config{
+		ServerName: cfg.SMTPHost,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	switch cfg.SMTPTLS {
+	case TLSStartTLS:
+		dialer.SSL = false
+		dialer.StartTLSPolicy = gomail.MandatoryStartTLS
+	case TLSImplicit:
+		dialer.SSL = true
+	case TLSNone:
+		if cfg.SMTPUsername != "" {
+			return nil, errors.New("refusing to send SMTP credentials without TLS")
+		}
+		dialer.SSL = false
+		dialer.StartTLSPolicy = gomail.NoStartTLS
+	default:
+		return nil, fmt.Errorf("unknown SMTP_TLS mode %q", cfg.SMTPTLS)
+	}
+
+	return &SMTP{dialer: dialer, from: cfg.From}, nil
+}
+
+func (s *SMTP) Send(msg Message) error {
+	m, err := build(s.from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := s.dialer.DialAndSend(m); err != nil {
+		return fmt.Errorf("smtp send: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultLocale is used when a user's locale has no variant of a template.
+// Every template must exist in it.
+const DefaultLocale = "en"
+
+// Template names
+const (
+	TemplateVerify         = "verify"
+	TemplateReset          = "reset"
+	TemplateAccountLocked  = "account_locked"
+	TemplateContactChanged = "contact_changed"
+	TemplateLoginAlert     = "login_alert"
+	TemplateDigest         = "digest"
+	TemplateReminder       = "reminder"
+)
+
+var (
+	ErrUnknownTemplate = errors.New("unknown email template")
+	ErrUnknownLocale   = errors.New("unsupported locale")
+)
+
+type VerifyData struct {
+	Otp              string
+	ExpiresInMinutes int
+}
+
+type ResetData struct {
+	Otp      string
+	ResetURL string
+}
+
+type AccountLockedData struct {
+	UnlockURL   string
+	LockedUntil string
+}
+
+// ContactChangedData describes a changed email or phone. Field is "email" or
+// "phone"; NewValue should already be masked.
+type ContactChangedData struct {
+	Field     string
+	NewValue  string
+	ChangedAt string
+}
+
+type LoginAlertData struct {
+	Device     string
+	IP         string
+	SignedInAt string
+}
+
+// DigestData is a summary of recent activity. Period is daily, weekly or
+// monthly.
+type DigestData struct {
+	Name   string
+	Period string
+	Items  []DigestItem
+}
+
+type DigestItem struct {
+	Title  string
+	Detail string
+}
+
+type ReminderData struct {
+	Name    string
+	Title   string
+	Amount  string
+	DueDate string
+	Detail  string
+	URL     string
+}
+
+// samples is the data each template is previewed and checked with. It is
+// also the list of templates that are loaded, so every template needs one.
+var samples = map[string]any{
+	TemplateVerify: VerifyData{Otp: "123456", ExpiresInMinutes: 10},
+	TemplateReset:  ResetData{Otp: "123456", ResetURL: "https://finora.example/reset-password?token=sample"},
+	TemplateAccountLocked: AccountLockedData{
+		UnlockURL:   "https://finora.example/auth/unlock?token=sample",
+		LockedUntil: FormatTime(time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC)),
+	},
+	TemplateContactChanged: ContactChangedData{
+		Field:     "email",
+		NewValue:  "n******@example.com",
+		ChangedAt: FormatTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+	},
+	TemplateLoginAlert: LoginAlertData{
+		Device:     "Pixel 8",
+		IP:         "203.0.113.7",
+		SignedInAt: FormatTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)),
+	},
+	TemplateDigest: DigestData{
+		Name:   "Asha",
+		Period: "weekly",
+		Items: []DigestItem{
+			{Title: "Dinner at Café Lota", Detail: "Ravi added ₹1,200, your share is ₹400"},
+			{Title: "Groceries budget", Detail: "82% used"},
+		},
+	},
+	TemplateReminder: ReminderData{
+		Name:    "Asha",
+		Title:   "Car loan EMI",
+		Amount:  "₹12,500",
+		DueDate: "05 Jan 2025",
+		Detail:  "Paid from HDFC savings.",
+		URL:     "https://finora.example/emis/1",
+	},
+}
+
+const timeLayout = "02 Jan 2006 15:04 MST"
+
+// FormatTime formats a time for display in an email
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// Templates is the registry of email templates embedded in the binary. Each
+// template lives in templates/<locale>/<name>.html and <name>.txt. The text
+// part also defines the subject in a "subject" block.
+type Templates struct {
+	variants map[string]map[string]*variant
+	locales  []string
+}
+
+type variant struct {
+	html    *htmltemplate.Template
+	text    *texttemplate.Template
+	version string
+}
+
+// TemplateInfo describes a template for admins. Versions maps each locale
+// to a hash of its files, which changes whenever the wording does.
+type TemplateInfo struct {
+	Name     string            `json:"name"`
+	Versions map[string]string `json:"versions"`
+}
+
+// LoadTemplates parses every embedded template and renders each one with its
+// sample data, so a broken template stops the server at startup rather than
+// an email at send time.
+func LoadTemplates() (*Templates, error) {
+	entries, err := fs.ReadDir(templateFS, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Templates{variants: map[string]map[string]*variant{}}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			t.locales = append(t.locales, entry.Name())
+		}
+	}
+	if !slices.Contains(t.locales, DefaultLocale) {
+		return nil, fmt.Errorf("email templates: default locale %q is missing", DefaultLocale)
+	}
+
+	for name, sample := range samples {
+		t.variants[name] = map[string]*variant{}
+		for _, locale := range t.locales {
+			v, err := loadVariant(locale, name)
+			if errors.Is(err, fs.ErrNotExist) && locale != DefaultLocale {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("email template %s/%s: %w", locale, name, err)
+			}
+			if _, err := v.render(sample); err != nil {
+				return nil, fmt.Errorf("email template %s/%s: %w", locale, name, err)
+			}
+			t.variants[name][locale] = v
+		}
+	}
+
+	return t, nil
+}
+
+func loadVariant(locale, name string) (*variant, error) {
+	base := path.Join("templates", locale, name)
+	htmlSource, err := fs.ReadFile(templateFS, base+".html")
+	if err != nil {
+		return nil, err
+	}
+	textSource, err := fs.ReadFile(templateFS, base+".txt")
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New(name).Option("missingkey=error").Parse(string(htmlSource))
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New(name).Option("missingkey=error").Parse(string(textSource))
+	if err != nil {
+		return nil, err
+	}
+	if text.Lookup("subject") == nil {
+		return nil, errors.New("text part does not define a subject")
+	}
+
+	sum := sha256.Sum256(append(append(htmlSource, 0), textSource...))
+	return &variant{html: html, text: text, version: hex.EncodeToString(sum[:6])}, nil
+}
+
+func (v *variant) render(data any) (Message, error) {
+	var subject, html, text bytes.Buffer
+	if err := v.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := v.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := v.html.Execute(&html, data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// Render builds the named email for to in the closest available locale
+func (t *Templates) Render(name, locale, to string, data any) (Message, error) {
+	variants, ok := t.variants[name]
+	if !ok {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+
+	locale = t.resolve(variants, locale)
+	msg, err := variants[locale].render(data)
+	if err != nil {
+		return Message{}, fmt.Errorf("email template %s/%s: %w", locale, name, err)
+	}
+
+	msg.To = to
+	msg.Template = name
+	msg.Locale = locale
+	msg.Version = variants[locale].version
+	return msg, nil
+}
+
+// Preview renders the named template with its sample data
+func (t *Templates) Preview(name, locale string) (Message, error) {
+	if locale != "" && !t.SupportsLocale(locale) {
+		return Message{}, fmt.Errorf("%w: %s", ErrUnknownLocale, locale)
+	}
+	return t.Render(name, locale, "", samples[name])
+}
+
+// List describes every template, sorted by name
+func (t *Templates) List() []TemplateInfo {
+	infos := make([]TemplateInfo, 0, len(t.variants))
+	for name, variants := range t.variants {
+		info := TemplateInfo{Name: name, Versions: map[string]string{}}
+		for locale, v := range variants {
+			info.Versions[locale] = v.version
+		}
+		infos = append(infos, info)
+	}
+
+	slices.SortFunc(infos, func(a, b TemplateInfo) int { return strings.Compare(a.Name, b.Name) })
+	return infos
+}
+
+// Locales returns the locales that have templates
+func (t *Templates) Locales() []string {
+	return slices.Clone(t.locales)
+}
+
+// SupportsLocale reports whether locale, or the language it names, has
+// templates. "hi-IN" is supported when "hi" is.
+func (t *Templates) SupportsLocale(locale string) bool {
+	locale = NormalizeLocale(locale)
+	language, _, _ := strings.Cut(locale, "-")
+	return slices.Contains(t.locales, locale) || slices.Contains(t.locales, language)
+}
+
+// resolve picks the variant for locale: an exact match, then its language,
+// then the default
+func (t *Templates) resolve(variants map[string]*variant, locale string) string {
+	locale = NormalizeLocale(locale)
+	if _, ok := variants[locale]; ok {
+		return locale
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	if _, ok := variants[language]; ok {
+		return language
+	}
+	return DefaultLocale
+}
+
+// NormalizeLocale lowercases a locale tag and uses - as its separator
+func NormalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/repo"
+	"github.com/ayyoob-k-a/finora/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// sessionTouchInterval limits how often a session's last-seen time is written
+const sessionTouchInterval = time.Minute
+
+var (
+	errMissingToken   = errors.New("missing bearer token")
+	errUnknownUser    = errors.New("user no longer exists")
+	errSessionRevoked = errors.New("session has been revoked")
+	errInvalidPAT     = errors.New("invalid or expired access token")
+	errSessionOnly    = errors.New("this endpoint requires a signed-in session")
+	errMissingScope   = errors.New("access token is missing the required scope")
+	errRoleChanged    = errors.New("role has changed, refresh your token")
+	errNoPermission   = errors.New("you do not have permission to do this")
+	errUserDisabled   = errors.New("account has been disabled")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	UserID     int
+	SessionID  int
+	Email      string
+	Username   string
+	IsVerified bool
+	Role       string
+	// TokenID and Scopes are set when the caller used a personal access token
+	TokenID int
+	Scopes  []string
+}
+
+// IsPAT reports whether the request was authenticated with a personal access token
+func (p *Principal) IsPAT() bool {
+	return p.TokenID != 0
+}
+
+// HasScope reports whether the principal may act within scope. Signed-in
+// sessions carry every scope.
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsPAT() {
+		return true
+	}
+	return slices.Contains(p.Scopes, scope)
+}
+
+// Auth validates the bearer access token or personal access token on the
+// request and stores the resulting Principal on the context. Requests without
+// a valid token are rejected with 401.
+func Auth(repo *repo.Repo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			unauthorized(c, errMissingToken)
+			return
+		}
+
+		if strings.HasPrefix(tokenString, utils.PATPrefix) {
+			patAuth(c, repo, tokenString)
+			return
+		}
+
+		claims, err := utils.ParseToken(tokenString, utils.TokenTypeAccess)
+		if err != nil {
+			unauthorized(c, err)
+			return
+		}
+
+		session, err := repo.GetSession(claims.SessionID)
+		if err != nil || session.UserID != claims.UserID || session.RevokedAt != nil {
+			unauthorized(c, errSessionRevoked)
+			return
+		}
+
+		user, err := repo.GetUserByID(claims.UserID)
+		if err != nil {
+			unauthorized(c, errUnknownUser)
+			return
+		}
+		if user.DisabledAt != nil {
+			unauthorized(c, errUserDisabled)
+			return
+		}
+
+		// The role is embedded in the token; once it changes the client has
+		// to refresh to pick up the new one.
+		if claims.Role != user.Role {
+			unauthorized(c, errRoleChanged)
+			return
+		}
+
+		if time.Since(session.LastSeenAt) > sessionTouchInterval {
+			if err := repo.TouchSession(session.ID, c.ClientIP()); err != nil {
+				log.Printf("failed to update session %d: %v", session.ID, err)
+			}
+		}
+
+		c.Set(principalKey, principalFor(user, session.ID, nil))
+		c.Next()
+	}
+}
+
+func patAuth(c *gin.Context, repo *repo.Repo, tokenString string) {
+	token, err := repo.GetPATByHash(utils.HashSecret(tokenString))
+	if err != nil || token.RevokedAt != nil ||
+		(token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt)) {
+		unauthorized(c, errInvalidPAT)
+		return
+	}
+
+	user, err := repo.GetUserByID(token.UserID)
+	if err != nil {
+		unauthorized(c, errUnknownUser)
+		return
+	}
+	if user.DisabledAt != nil {
+		unauthorized(c, errUserDisabled)
+		return
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) > sessionTouchInterval {
+		if err := repo.TouchPAT(token.ID); err != nil {
+			log.Printf("failed to update access token %d: %v", token.ID, err)
+		}
+	}
+
+	c.Set(principalKey, principalFor(user, 0, token))
+	c.Next()
+}
+
+func principalFor(user *domain.User, sessionID int, token *domain.PersonalAccessToken) *Principal {
+	principal := &Principal{
+		UserID:     user.ID,
+		SessionID:  sessionID,
+		Email:      user.Email,
+		Username:   user.Username,
+		IsVerified: user.IsVerified,
+		Role:       user.Role,
+	}
+	if token != nil {
+		principal.TokenID = token.ID
+		principal.Scopes = token.Scopes
+	}
+	return principal
+}
+
+// RequireScope rejects personal access tokens that were not granted scope.
+// It must run after Auth.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentUser(c)
+		if !ok {
+			unauthorized(c, errMissingToken)
+			return
+		}
+		if !principal.HasScope(scope) {
+			forbidden(c, errMissingScope)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession rejects personal access tokens, for account management
+// endpoints that only a signed-in user may use. It must run after Auth.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentUser(c)
+		if !ok {
+			unauthorized(c, errMissingToken)
+			return
+		}
+		if principal.IsPAT() {
+			forbidden(c, errSessionOnly)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Can reports whether the principal's role grants permission. Personal access
+// tokens are limited to their scopes and never carry role permissions.
+func (p *Principal) Can(permission string) bool {
+	if p.IsPAT() {
+		return false
+	}
+	return domain.RoleHasPermission(p.Role, permission)
+}
+
+// RequirePermission rejects callers whose role lacks any of permissions. It
+// must run after Auth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentUser(c)
+		if !ok {
+			unauthorized(c, errMissingToken)
+			return
+		}
+		for _, permission := range permissions {
+			if !principal.Can(permission) {
+				forbidden(c, errNoPermission)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// CurrentUser returns the Principal set by Auth
+func CurrentUser(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func unauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="finora"`)
+	response.NewCommonResponse(c, "Unauthorized", "error", err, http.StatusUnauthorized, nil)
+	c.Abort()
+}
+
+func forbidden(c *gin.Context, err error) {
+	response.NewCommonResponse(c, "Forbidden", "error", err, http.StatusForbidden, nil)
+	c.Abort()
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/repo"
+	"github.com/ayyoob-k-a/finora/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	keyDir, err := os.MkdirTemp("", "finora-keys")
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = utils.InitSigningKeys(configs.JWT{Issuer: "finora-test", Algorithm: "EdDSA", KeyDir: keyDir, KeyID: "test", GenerateKey: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(keyDir)
+	os.Exit(code)
+}
+
+func newTestRepo(t *testing.T) (*repo.Repo, *gorm.DB) {
+	t.Helper()
+
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&domain.User{}, &domain.Session{}, &domain.RefreshToken{}); err != nil {
+		t.Fatal(err)
+	}
+	return repo.NewRepo(db, nil), db
+}
+
+func TestAuthRejectsRevokedSessions(t *testing.T) {
+	tests := []struct {
+		name   string
+		revoke func(*testing.T, *repo.Repo, *gorm.DB, *domain.User, *domain.Session)
+		want   int
+	}{
+		{
+			name: "active session",
+			want: http.StatusOK,
+		},
+		{
+			name: "session signed out",
+			revoke: func(t *testing.T, r *repo.Repo, _ *gorm.DB, user *domain.User, session *domain.Session) {
+				if _, err := r.RevokeSession(user.ID, session.ID); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "every session signed out",
+			revoke: func(t *testing.T, r *repo.Repo, _ *gorm.DB, user *domain.User, _ *domain.Session) {
+				if err := r.RevokeAllSessions(user.ID, 0); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "session deleted",
+			revoke: func(_ *testing.T, _ *repo.Repo, db *gorm.DB, _ *domain.User, session *domain.Session) {
+				db.Delete(session)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "session moved to another user",
+			revoke: func(_ *testing.T, _ *repo.Repo, db *gorm.DB, user *domain.User, session *domain.Session) {
+				db.Model(session).Update("user_id", user.ID+1)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "account disabled",
+			revoke: func(_ *testing.T, _ *repo.Repo, db *gorm.DB, user *domain.User, _ *domain.Session) {
+				db.Model(user).Update("disabled_at", time.Now())
+			},
+			want: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, db := newTestRepo(t)
+			user := &domain.User{Email: "auth@example.com", Role: domain.RoleUser, IsVerified: true}
+			if err := r.CreateUser(user); err != nil {
+				t.Fatal(err)
+			}
+			session := &domain.Session{UserID: user.ID, LastSeenAt: time.Now()}
+			if err := r.CreateSession(session); err != nil {
+				t.Fatal(err)
+			}
+			pair, err := utils.GenerateToken(user.ID, session.ID, "", user.Role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.revoke != nil {
+				tt.revoke(t, r, db, user, session)
+			}
+
+			router := gin.New()
+			router.GET("/me", Auth(r), func(c *gin.Context) {
+				principal, _ := CurrentUser(c)
+				c.String(http.StatusOK, "%d", principal.SessionID)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
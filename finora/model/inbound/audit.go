@@ -0,0 +1,15 @@
+package inbound
+
+import "time"
+
+// AuditQuery filters the audit log. Zero values match everything. An action
+// ending in * matches by prefix, e.g. admin.*
+type AuditQuery struct {
+	ActorID    int       `form:"actor_id"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   int       `form:"target_id"`
+	IP         string    `form:"ip"`
+	Since      time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
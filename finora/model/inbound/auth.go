@@ -1,15 +1,118 @@
 package inbound
 
 type Signup struct {
-	Email           string `json:"email" `
-	Password        string `json:"password"`
-	Phone           string `json:"phone" `
-	ConfirmPassword string `json:"confirm_password"`
-	Username        string `json:"username" `
-	IsVerified      bool   `json:"is_verified"`
+	Email           string `json:"email" binding:"required,email"`
+	Password        string `json:"password" binding:"required,max=72"`
+	Phone           string `json:"phone" binding:"omitempty,e164"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=Password"`
+	Username        string `json:"username" binding:"required,username"`
+	Locale          string `json:"locale" binding:"omitempty,max=16"`
 }
 
 type Login struct {
 	Identifier string `json:"identifier"` // email or phone
 	Password   string `json:"password"`
+	Device     string `json:"device"`
+}
+
+// Client describes where a request came from. It is filled in by the handler
+// and recorded on the session.
+type Client struct {
+	IP        string
+	UserAgent string
+	Device    string
+}
+
+type VerifyOtp struct {
+	Email string `json:"email" binding:"required,email"`
+	Otp   string `json:"otp" binding:"required,len=6,numeric"`
+}
+
+type ResendOtp struct {
+	Identifier string `json:"identifier" binding:"required"` // email or phone
+}
+
+type Refresh struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type ForgotPassword struct {
+	Identifier string `json:"identifier" binding:"required"` // email or phone
+}
+
+// ResetPassword accepts either the emailed link token, or the identifier
+// together with the emailed code.
+type ResetPassword struct {
+	Token       string `json:"token"`
+	Identifier  string `json:"identifier"`
+	Otp         string `json:"otp"`
+	NewPassword string `json:"new_password" binding:"required,max=72"`
+}
+
+type SendOtp struct {
+	Identifier string `json:"identifier" binding:"required"` // email or phone
+}
+
+type VerifyLoginOtp struct {
+	Identifier string `json:"identifier" binding:"required"` // email or phone
+	Otp        string `json:"otp" binding:"required,len=6,numeric"`
+	Device     string `json:"device"`
+}
+
+// Reauthenticate confirms the signed-in user's identity before a sensitive
+// change. Exactly one of the password, a code sent with /me/reauth/otp or a
+// TOTP or recovery code is needed.
+type Reauthenticate struct {
+	Password string `json:"password" binding:"max=72"`
+	Otp      string `json:"otp" binding:"omitempty,len=6,numeric"`
+	Code     string `json:"code"`
+}
+
+type TwoFactorCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// VerifyMFA completes a login that returned an mfa_required challenge. Either
+// a TOTP code or a recovery code must be given.
+type VerifyMFA struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	Device         string `json:"device"`
+}
+
+// UpdateProfile changes only the fields that are present. New email addresses
+// and phone numbers take effect once confirmed with ConfirmContactChange.
+type UpdateProfile struct {
+	Username *string `json:"username" binding:"omitempty,username"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+	Phone    *string `json:"phone" binding:"omitempty,e164"`
+	Locale   *string `json:"locale" binding:"omitempty,max=16"`
+}
+
+type ConfirmContactChange struct {
+	Identifier string `json:"identifier" binding:"required"` // the new email or phone
+	Otp        string `json:"otp" binding:"required,len=6,numeric"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required,max=72"`
+}
+
+// DeleteAccount confirms a deletion request. The password is required for
+// accounts that have one; accounts without one must have re-authenticated
+// recently instead.
+type DeleteAccount struct {
+	Password string `json:"password" binding:"max=72"`
+}
+
+type SetRole struct {
+	Role string `json:"role" binding:"required,oneof=user support admin"`
+}
+
+type CreatePAT struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"`
 }
@@ -0,0 +1,9 @@
+package inbound
+
+// NotificationQuery filters a user's notifications. Cursor is the next_cursor
+// of the previous page and is empty for the first one.
+type NotificationQuery struct {
+	Cursor string `form:"cursor"`
+	Type   string `form:"type"`
+	Unread bool   `form:"unread"`
+}
@@ -1,17 +1,179 @@
 package response
 
-import "github.com/gin-gonic/gin"
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/gin-gonic/gin"
+)
 
 type User struct {
 	ID       int    `json:"id"`
 	Email    string `json:"email"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
+}
+
+// AdminUser is a user as seen by support staff. The login status fields are
+// only filled in when a single user is fetched.
+type AdminUser struct {
+	ID               int        `json:"id"`
+	Email            string     `json:"email"`
+	Phone            string     `json:"phone"`
+	Username         string     `json:"username"`
+	Role             string     `json:"role"`
+	IsVerified       bool       `json:"is_verified"`
+	Disabled         bool       `json:"disabled"`
+	DisabledAt       *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt        string     `json:"created_at"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	ActiveSessions   int        `json:"active_sessions"`
+	LastLoginAt      *time.Time `json:"last_login_at,omitempty"`
+	FailedLogins     int        `json:"failed_logins"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"`
+}
+
+type AuditPage struct {
+	Events []domain.AuditEvent `json:"events"`
+	Total  int64               `json:"total"`
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+// SecurityEvent is an audit event as shown to the user it concerns
+type SecurityEvent struct {
+	Action    string    `json:"action"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	ByAdmin   bool      `json:"by_admin"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SecurityEventPage struct {
+	Events []SecurityEvent `json:"events"`
+	Total  int64           `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+type EmailPage struct {
+	Emails []domain.OutboxEmail `json:"emails"`
+	Total  int64                `json:"total"`
+	Limit  int                  `json:"limit"`
+	Offset int                  `json:"offset"`
+}
+
+// NotificationPage is one page of notifications, newest first. NextCursor is
+// empty on the last page.
+type NotificationPage struct {
+	Notifications []domain.Notification `json:"notifications"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+	Limit         int                   `json:"limit"`
+}
+
+type UnreadCount struct {
+	Unread int64 `json:"unread"`
+}
+
+// EmailPreview is a template rendered with sample data
+type EmailPreview struct {
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
+	Version  string `json:"version"`
+	Subject  string `json:"subject"`
+	HTML     string `json:"html"`
+	Text     string `json:"text"`
+}
+
+type UserPage struct {
+	Users  []AdminUser `json:"users"`
+	Total  int64       `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+type Profile struct {
+	ID          int    `json:"id"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Username    string `json:"username"`
+	Role        string `json:"role"`
+	Locale      string `json:"locale"`
+	IsVerified  bool   `json:"is_verified"`
+	HasPassword bool   `json:"has_password"`
+	CreatedAt   string `json:"created_at"`
+	// DeletionScheduledAt is set while a requested account deletion is pending
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+}
+
+// ProfileUpdate is the profile after an update, listing the new addresses a
+// confirmation code was sent to.
+type ProfileUpdate struct {
+	Profile            Profile  `json:"profile"`
+	VerificationSentTo []string `json:"verification_sent_to"`
 }
 
 type AuthResponse struct {
 	UserID       int    `json:"user_id"`
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// MFAChallenge is returned by login instead of an AuthResponse when the user
+// has two-factor authentication enabled.
+type MFAChallenge struct {
+	MFARequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresIn      int    `json:"expires_in"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCResult is the outcome of a provider callback. Exactly one field is set.
+type OIDCResult struct {
+	Auth           *AuthResponse `json:"auth,omitempty"`
+	MFAChallenge   *MFAChallenge `json:"mfa_challenge,omitempty"`
+	LinkedIdentity *Identity     `json:"linked_identity,omitempty"`
+}
+
+type PAT struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedPAT carries the plain token, which is only ever shown here
+type CreatedPAT struct {
+	PAT
+	Token string `json:"token"`
+}
+
+type Session struct {
+	ID         int       `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
 }
 
 type CommonResponse struct {
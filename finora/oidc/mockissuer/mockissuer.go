@@ -0,0 +1,217 @@
+// Package mockissuer is a tiny OpenID provider for local development and
+// tests. It approves every authorization request as a fixed user, unless the
+// request carries a login_hint, in which case it signs in that email address.
+package mockissuer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock"
+
+// User is the identity the issuer signs in
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type authorization struct {
+	user          User
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// Issuer is an http.Handler serving discovery, authorize, token and jwks
+// endpoints. URL must be the address it is reachable at. Tests can set
+// EditClaims to tamper with ID tokens before they are signed.
+type Issuer struct {
+	URL        string
+	ClientID   string
+	User       User
+	EditClaims func(claims jwt.MapClaims)
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+func New(issuerURL, clientID string, user User) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Issuer{
+		URL:      strings.TrimRight(issuerURL, "/"),
+		ClientID: clientID,
+		User:     user,
+		key:      key,
+		codes:    map[string]authorization{},
+	}, nil
+}
+
+func (i *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		i.discovery(w)
+	case "/authorize":
+		i.authorize(w, r)
+	case "/token":
+		i.token(w, r)
+	case "/jwks":
+		i.jwks(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.URL,
+		"authorization_endpoint":                i.URL + "/authorize",
+		"token_endpoint":                        i.URL + "/token",
+		"jwks_uri":                              i.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != i.ClientID || q.Get("response_type") != "code" || q.Get("redirect_uri") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	user := i.User
+	if hint := q.Get("login_hint"); hint != "" {
+		user = User{Subject: "mock-" + hint, Email: hint, EmailVerified: true, Name: hint}
+	}
+
+	code := randomString()
+	i.mu.Lock()
+	i.codes[code] = authorization{
+		user:          user,
+		clientID:      q.Get("client_id"),
+		redirectURI:   q.Get("redirect_uri"),
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+	}
+	i.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	auth, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != auth.clientID || r.PostForm.Get("redirect_uri") != auth.redirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            i.URL,
+		"aud":            auth.clientID,
+		"sub":            auth.user.Subject,
+		"email":          auth.user.Email,
+		"email_verified": auth.user.EmailVerified,
+		"name":           auth.user.Name,
+		"nonce":          auth.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	if i.EditClaims != nil {
+		i.EditClaims(claims)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(i.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter) {
+	pub := i.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,242 @@
+// Package oidc is a minimal OpenID Connect relying party. It supports the
+// authorization code flow with PKCE against any issuer that publishes a
+// discovery document, so new providers only need configuration.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+)
+
+const discoveryTTL = time.Hour
+
+var (
+	ErrUnknownProvider = errors.New("unknown identity provider")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+)
+
+// Discovery is the subset of the OpenID provider metadata the client uses
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Tokens is the token endpoint response
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+}
+
+// Provider is one configured OpenID provider. Discovery and the provider's
+// keys are fetched lazily and cached.
+type Provider struct {
+	cfg    configs.OIDCProvider
+	client *http.Client
+
+	mu           sync.Mutex
+	discovery    *Discovery
+	discoveredAt time.Time
+	keys         *keySet
+}
+
+// Providers maps provider names, as used in URLs, to providers
+type Providers map[string]*Provider
+
+// NewProviders builds a provider for every configured entry
+func NewProviders(cfgs []configs.OIDCProvider) Providers {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	providers := Providers{}
+	for _, cfg := range cfgs {
+		providers[cfg.Name] = &Provider{cfg: cfg, client: client}
+	}
+	return providers
+}
+
+func (p Providers) Get(name string) (*Provider, error) {
+	provider, ok := p[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names lists the configured providers
+func (p Providers) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	return names
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL builds the URL the user is sent to for consent
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", CodeChallenge(codeVerifier))
+	query.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return doc.AuthorizationEndpoint + sep + query.Encode(), nil
+}
+
+// Exchange trades an authorization code for tokens
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Tokens, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var tokens Tokens
+	if err := p.do(req, &tokens); err != nil {
+		return nil, fmt.Errorf("token exchange: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token exchange: no id_token in response")
+	}
+
+	return &tokens, nil
+}
+
+// discover fetches the provider metadata, checking that it describes the
+// configured issuer.
+func (p *Provider) discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discoveredAt) < discoveryTTL {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimRight(p.cfg.Issuer, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc Discovery
+	if err := p.do(req, &doc); err != nil {
+		return nil, fmt.Errorf("discovery: %w", err)
+	}
+	if strings.TrimRight(doc.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("discovery: issuer mismatch, got %q", doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("discovery: incomplete provider metadata")
+	}
+
+	if p.keys == nil || p.keys.uri != doc.JWKSURI {
+		p.keys = &keySet{uri: doc.JWKSURI, client: p.client}
+	}
+	p.discovery = &doc
+	p.discoveredAt = time.Now()
+	return p.discovery, nil
+}
+
+func (p *Provider) keySet(ctx context.Context) (*Discovery, *keySet, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return doc, p.keys, nil
+}
+
+func (p *Provider) do(req *http.Request, out interface{}) error {
+	return doJSON(p.client, req, out)
+}
+
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// NewCodeVerifier returns a random PKCE code verifier
+func NewCodeVerifier() string {
+	return randomString(32)
+}
+
+// NewState returns a random value suitable for the state or nonce parameter
+func NewState() string {
+	return randomString(24)
+}
+
+// CodeChallenge derives the S256 PKCE challenge for a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,174 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/oidc"
+	"github.com/ayyoob-k-a/finora/oidc/mockissuer"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	clientID    = "finora"
+	redirectURL = "http://localhost/auth/oidc/mock/callback"
+)
+
+// flow is one authorization request made against the mock issuer, stopped at
+// the point where the provider redirects back to the callback.
+type flow struct {
+	code     string
+	nonce    string
+	verifier string
+}
+
+func newProvider(t *testing.T) (*oidc.Provider, *mockissuer.Issuer) {
+	t.Helper()
+
+	issuer, err := mockissuer.New("", clientID, mockissuer.User{
+		Subject:       "mock-user",
+		Email:         "mock.user@example.com",
+		EmailVerified: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(issuer)
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+
+	provider, err := oidc.NewProviders([]configs.OIDCProvider{{
+		Name:        "mock",
+		Issuer:      server.URL,
+		ClientID:    clientID,
+		RedirectURL: redirectURL,
+		Scopes:      []string{"openid", "email"},
+	}}).Get("mock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, issuer
+}
+
+// authorize follows the provider's consent URL and returns the code it
+// redirects back with.
+func authorize(t *testing.T, provider *oidc.Provider) flow {
+	t.Helper()
+
+	f := flow{nonce: oidc.NewState(), verifier: oidc.NewCodeVerifier()}
+	state := oidc.NewState()
+	authURL, err := provider.AuthCodeURL(context.Background(), state, f.nonce, f.verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("authorize returned %d", res.StatusCode)
+	}
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := location.Query().Get("state"); got != state {
+		t.Fatalf("state not echoed back, got %q", got)
+	}
+	f.code = location.Query().Get("code")
+	return f
+}
+
+// callback does what the callback handler does with the provider: exchange
+// the code and verify the ID token.
+func callback(provider *oidc.Provider, f flow) (*oidc.Claims, error) {
+	ctx := context.Background()
+	tokens, err := provider.Exchange(ctx, f.code, f.verifier)
+	if err != nil {
+		return nil, err
+	}
+	return provider.VerifyIDToken(ctx, tokens.IDToken, f.nonce)
+}
+
+func TestCallbackAcceptsValidLogin(t *testing.T) {
+	provider, _ := newProvider(t)
+
+	claims, err := callback(provider, authorize(t, provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "mock-user" || claims.Email != "mock.user@example.com" || !claims.EmailVerified {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+}
+
+func TestCallbackRejectsNonceMismatch(t *testing.T) {
+	provider, _ := newProvider(t)
+
+	f := authorize(t, provider)
+	f.nonce = oidc.NewState()
+	if _, err := callback(provider, f); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+	}
+}
+
+func TestCallbackRejectsTamperedClaims(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(jwt.MapClaims)
+	}{
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "someone-else" }},
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"expired", func(c jwt.MapClaims) {
+			c["iat"] = time.Now().Add(-time.Hour).Unix()
+			c["exp"] = time.Now().Add(-30 * time.Minute).Unix()
+		}},
+		{"missing nonce", func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{"missing subject", func(c jwt.MapClaims) { c["sub"] = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, issuer := newProvider(t)
+			issuer.EditClaims = tt.edit
+
+			if _, err := callback(provider, authorize(t, provider)); !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Fatalf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCallbackRejectsBadPKCEVerifier(t *testing.T) {
+	provider, _ := newProvider(t)
+
+	f := authorize(t, provider)
+	f.verifier = oidc.NewCodeVerifier()
+	if _, err := callback(provider, f); err == nil {
+		t.Fatal("expected the token exchange to fail")
+	}
+}
+
+func TestCallbackRejectsReusedCode(t *testing.T) {
+	provider, _ := newProvider(t)
+
+	f := authorize(t, provider)
+	if _, err := callback(provider, f); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := callback(provider, f); err == nil {
+		t.Fatal("expected the second exchange of a code to fail")
+	}
+}
@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachedSet looks passwords up in a local copy of a breached-password
+// dataset laid out like the Pwned Passwords range API: one file per 5 hex
+// digit SHA-1 prefix, named <PREFIX>.txt, holding "SUFFIX:COUNT" lines.
+// Only the file for a password's prefix is read, so the dataset can be far
+// larger than memory.
+type BreachedSet struct {
+	dir      string
+	minCount int
+}
+
+// OpenBreachedSet checks that dir exists and returns a set that treats a
+// password as breached once it has been seen at least minCount times.
+func OpenBreachedSet(dir string, minCount int) (*BreachedSet, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("breached password dataset: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password dataset: %s is not a directory", dir)
+	}
+	if minCount < 1 {
+		minCount = 1
+	}
+
+	return &BreachedSet{dir: dir, minCount: minCount}, nil
+}
+
+// Contains reports whether password is in the dataset
+func (s *BreachedSet) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := os.Open(filepath.Join(s.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		candidate, countText, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(candidate, suffix) {
+			continue
+		}
+
+		count, err := strconv.Atoi(countText)
+		if err != nil {
+			// Lists without counts only name breached passwords
+			count = 1
+		}
+		return count >= s.minCount, nil
+	}
+	return false, scanner.Err()
+}
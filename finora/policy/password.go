@@ -0,0 +1,175 @@
+// Package policy decides whether a password is acceptable: long enough, hard
+// enough to guess, unrelated to the account and not known from breaches.
+package policy
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ayyoob-k-a/finora/configs"
+)
+
+// Rule names reported in violations
+const (
+	RuleMinLength    = "min_length"
+	RuleEntropy      = "entropy"
+	RuleContainsUser = "contains_user_info"
+	RuleBreached     = "breached"
+)
+
+// Violation is one failed rule with a message fit to show the user
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ViolationError lists every rule a password failed
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		messages = append(messages, v.Message)
+	}
+	return "password " + strings.Join(messages, ", ")
+}
+
+// Account holds what a password must not be based on
+type Account struct {
+	Email    string
+	Username string
+}
+
+type Password struct {
+	minLength  int
+	minEntropy float64
+	breached   *BreachedSet
+}
+
+// NewPassword builds the policy described by cfg. Breach checks are skipped
+// when no dataset directory is configured.
+func NewPassword(cfg configs.PasswordPolicy) (*Password, error) {
+	p := &Password{
+		minLength:  cfg.MinLength,
+		minEntropy: cfg.MinEntropy,
+	}
+	if cfg.BreachedDir != "" {
+		set, err := OpenBreachedSet(cfg.BreachedDir, cfg.BreachedMinCount)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = set
+	}
+	return p, nil
+}
+
+// Check returns a *ViolationError naming every rule the password fails, or
+// nil if it is acceptable.
+func (p *Password) Check(password string, account Account) error {
+	var violations []Violation
+
+	if utf8.RuneCountInString(password) < p.minLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters", p.minLength),
+		})
+	}
+
+	if p.minEntropy > 0 && EstimateEntropy(password) < p.minEntropy {
+		violations = append(violations, Violation{
+			Rule:    RuleEntropy,
+			Message: "is too easy to guess, use a longer mix of letters, digits and symbols",
+		})
+	}
+
+	if part := containedAccountInfo(password, account); part != "" {
+		violations = append(violations, Violation{
+			Rule:    RuleContainsUser,
+			Message: "must not contain your " + part,
+		})
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, Violation{
+				Rule:    RuleBreached,
+				Message: "has appeared in a data breach, choose a different one",
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+	return nil
+}
+
+// EstimateEntropy gives a rough strength in bits: the size of the character
+// classes used, raised to the length, with repeated characters counting for
+// half.
+func EstimateEntropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	seen := make(map[rune]bool)
+	length := 0.0
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r) && r < unicode.MaxASCII:
+			lower = true
+		case unicode.IsUpper(r) && r < unicode.MaxASCII:
+			upper = true
+		case unicode.IsDigit(r) && r < unicode.MaxASCII:
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+
+		if seen[r] {
+			length += 0.5
+		} else {
+			seen[r] = true
+			length++
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return length * math.Log2(float64(pool))
+}
+
+// containedAccountInfo names the piece of account information found in the
+// password, ignoring case. Parts shorter than three characters are ignored.
+func containedAccountInfo(password string, account Account) string {
+	lowered := strings.ToLower(password)
+
+	local, _, _ := strings.Cut(strings.ToLower(account.Email), "@")
+	if len(local) >= 3 && strings.Contains(lowered, local) {
+		return "email address"
+	}
+
+	username := strings.ToLower(account.Username)
+	if len(username) >= 3 && strings.Contains(lowered, username) {
+		return "username"
+	}
+	return ""
+}
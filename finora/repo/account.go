@@ -0,0 +1,95 @@
+package repo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+)
+
+// ScheduleDeletion sets when the user's account will be deleted, or cancels
+// a pending deletion when at is nil.
+func (r *Repo) ScheduleDeletion(userID int, at *time.Time) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ? AND anonymized_at IS NULL", userID).
+		Update("deletion_scheduled_at", at).Error
+}
+
+// ListUsersDueForDeletion returns users whose grace period has run out
+func (r *Repo) ListUsersDueForDeletion(now time.Time, limit int) ([]domain.User, error) {
+	var users []domain.User
+	err := r.db.
+		Where("deletion_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Order("deletion_scheduled_at").
+		Limit(limit).
+		Find(&users).Error
+
+	return users, err
+}
+
+// AnonymizeUser removes everything that identifies the user. Credentials,
+// sessions, linked identities and codes are deleted outright; the user row
+// itself is kept with its personal fields cleared so that records shared
+// with other users stay consistent. Audit events are retained until the
+// audit retention period removes them. throttleKey names the account's
+// failed-login counter, which is removed as well.
+func (r *Repo) AnonymizeUser(user *domain.User, throttleKey string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		byUser := []any{
+			&domain.Session{},
+			&domain.RefreshToken{},
+			&domain.TwoFactor{},
+			&domain.RecoveryCode{},
+			&domain.ExternalIdentity{},
+			&domain.PersonalAccessToken{},
+			&domain.DataExport{},
+			&domain.Notification{},
+			&domain.Otp{},
+		}
+		for _, model := range byUser {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		identifiers := []string{}
+		for _, identifier := range []string{user.Email, user.Phone} {
+			if identifier != "" {
+				identifiers = append(identifiers, identifier)
+			}
+		}
+		if len(identifiers) > 0 {
+			if err := tx.Where("identifier IN ?", identifiers).Delete(&domain.Otp{}).Error; err != nil {
+				return err
+			}
+			// Queued and sent mail and the text message log name the user
+			// by address.
+			for _, model := range []any{&domain.OutboxEmail{}, &domain.SMSMessage{}} {
+				if err := tx.Where("recipient IN ?", identifiers).Delete(model).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Where("link_user_id = ?", user.ID).Delete(&domain.OAuthState{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("key = ?", throttleKey).Delete(&domain.LoginThrottle{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(&domain.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]any{
+				"email":                 "",
+				"phone":                 "",
+				"username":              fmt.Sprintf("deleted-user-%d", user.ID),
+				"password":              "",
+				"is_verified":           false,
+				"disabled_at":           now,
+				"deletion_scheduled_at": nil,
+				"anonymized_at":         now,
+			}).Error
+	})
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+// SearchUsers matches query against email, phone and username and returns one
+// page of users along with the total number of matches.
+func (r *Repo) SearchUsers(query string, limit, offset int) ([]domain.User, int64, error) {
+	db := r.db.Model(&domain.User{})
+	if query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		db = db.Where("email ILIKE ? OR phone ILIKE ? OR username ILIKE ?", pattern, pattern, pattern)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []domain.User
+	err := db.Order("id").Limit(limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// SetUserDisabled disables the user at the given time, or enables them again
+// when at is nil. Anonymized accounts stay disabled. It reports false if the
+// user does not exist or has been anonymized.
+func (r *Repo) SetUserDisabled(userID int, at *time.Time) (bool, error) {
+	res := r.db.Model(&domain.User{}).
+		Where("id = ? AND anonymized_at IS NULL", userID).
+		Update("disabled_at", at)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// LastSessionAt returns when the user last signed in, or nil if never
+func (r *Repo) LastSessionAt(userID int) (*time.Time, error) {
+	var sessions []domain.Session
+	err := r.db.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&sessions).Error
+	if err != nil || len(sessions) == 0 {
+		return nil, err
+	}
+
+	return &sessions[0].CreatedAt, nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+func (r *Repo) CreateAuditEvent(event *domain.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+// ListAuditEvents returns one page of events matching query, newest first,
+// along with the total number of matches.
+func (r *Repo) ListAuditEvents(query inbound.AuditQuery, limit, offset int) ([]domain.AuditEvent, int64, error) {
+	db := r.db.Model(&domain.AuditEvent{})
+	if query.ActorID != 0 {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if prefix, ok := strings.CutSuffix(query.Action, "*"); ok {
+		db = db.Where("action LIKE ?", escapeLike(prefix)+"%")
+	} else if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.TargetType != "" {
+		db = db.Where("target_type = ?", query.TargetType)
+	}
+	if query.TargetID != 0 {
+		db = db.Where("target_id = ?", query.TargetID)
+	}
+	if query.IP != "" {
+		db = db.Where("ip = ?", query.IP)
+	}
+	if !query.Since.IsZero() {
+		db = db.Where("created_at >= ?", query.Since)
+	}
+	if !query.Until.IsZero() {
+		db = db.Where("created_at < ?", query.Until)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []domain.AuditEvent
+	err := db.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
+
+// ListUserAuditEvents returns events done by or to the user, newest first
+func (r *Repo) ListUserAuditEvents(userID, limit, offset int) ([]domain.AuditEvent, int64, error) {
+	db := r.db.Model(&domain.AuditEvent{}).
+		Where("actor_id = ? OR (target_type = ? AND target_id = ?)", userID, domain.AuditTargetUser, userID)
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var events []domain.AuditEvent
+	err := db.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&events).Error
+	return events, total, err
+}
+
+// PurgeAuditEvents deletes events older than before and reports how many
+// were removed.
+func (r *Repo) PurgeAuditEvents(before time.Time) (int64, error) {
+	res := r.db.Where("created_at < ?", before).Delete(&domain.AuditEvent{})
+	return res.RowsAffected, res.Error
+}
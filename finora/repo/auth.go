@@ -2,28 +2,86 @@ package repo
 
 import (
 	"errors"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/hasher"
 	"github.com/ayyoob-k-a/finora/model/inbound"
 	"github.com/ayyoob-k-a/finora/model/response"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/ayyoob-k-a/finora/utils"
 	"gorm.io/gorm"
 )
 
 type Repo struct {
-	db *gorm.DB
+	db     *gorm.DB
+	hasher hasher.Hasher
+	dummy  *dummyHash
 
 	// Add fields as needed for your repository
 }
 
-func NewRepo(db *gorm.DB) *Repo {
+// dummyHash is a hash of a throwaway password, made on first use with the
+// current hasher settings.
+type dummyHash struct {
+	mu   sync.Mutex
+	hash string
+}
+
+func NewRepo(db *gorm.DB, hasher hasher.Hasher) *Repo {
 	return &Repo{
-		db: db,
+		db:     db,
+		hasher: hasher,
+		dummy:  &dummyHash{},
 	}
 }
+
+func (r *Repo) dummyPasswordHash() (string, error) {
+	r.dummy.mu.Lock()
+	defer r.dummy.mu.Unlock()
+
+	if r.dummy.hash == "" {
+		hash, err := r.hasher.Hash(utils.RandomID())
+		if err != nil {
+			return "", err
+		}
+		r.dummy.hash = hash
+	}
+	return r.dummy.hash, nil
+}
+
+// Transaction runs fn with a Repo bound to a single database transaction,
+// which is committed if fn returns nil and rolled back otherwise.
+func (r *Repo) Transaction(fn func(tx *Repo) error) error {
+	return r.db.Transaction(func(db *gorm.DB) error {
+		return fn(&Repo{db: db, hasher: r.hasher, dummy: r.dummy})
+	})
+}
+
+// VerifyDummyPassword checks password against a hash no password matches.
+// It is called when there is no account or no stored password to check, so
+// that the answer takes as long as for a wrong password and does not reveal
+// which accounts exist. Only hasher.ErrBusy is returned.
+func (r *Repo) VerifyDummyPassword(password string) error {
+	hash, err := r.dummyPasswordHash()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.hasher.Verify(hash, password)
+	if errors.Is(err, hasher.ErrBusy) {
+		return err
+	}
+	return nil
+}
 func (r *Repo) Signup(data domain.User) (int, error) {
 	var existing domain.User
-	err := r.db.Where("email = ?", data.Email).First(&existing).Error
+	query := r.db.Where("email = ?", data.Email)
+	if data.Phone != "" {
+		query = query.Or("phone = ?", data.Phone)
+	}
+	err := query.First(&existing).Error
 	if err == nil {
 		return 0, errors.New("user already exists")
 	}
@@ -31,7 +89,7 @@ func (r *Repo) Signup(data domain.User) (int, error) {
 		return 0, err
 	}
 
-	data.Password, err = hashPassword(data.Password)
+	data.Password, err = r.hasher.Hash(data.Password)
 	if err != nil {
 		return 0, err
 	}
@@ -43,28 +101,40 @@ func (r *Repo) Signup(data domain.User) (int, error) {
 	return data.ID, nil
 }
 
-func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
-}
-
 func (r *Repo) Login(data inbound.Login) (*domain.User, error) {
 	var user domain.User
+	if data.Identifier == "" {
+		return nil, errors.New("invalid email/phone or password")
+	}
 
 	err := r.db.
 		Where("email = ? OR phone = ?", data.Identifier, data.Identifier).
 		First(&user).Error
 
-	if err != nil {
+	if err != nil || user.Password == "" {
+		if err := r.VerifyDummyPassword(data.Password); err != nil {
+			return nil, err
+		}
 		return nil, errors.New("invalid email/phone or password")
 	}
 
 	// Verify password
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(data.Password))
-	if err != nil {
+	ok, err := r.hasher.Verify(user.Password, data.Password)
+	if errors.Is(err, hasher.ErrBusy) {
+		return nil, err
+	}
+	if !ok {
 		return nil, errors.New("invalid email/phone or password")
 	}
 
+	// Upgrade hashes made with an older algorithm or weaker parameters
+	// while the plain password is at hand.
+	if r.hasher.NeedsRehash(user.Password) {
+		if err := r.UpdatePassword(user.ID, data.Password); err != nil {
+			log.Printf("failed to rehash password for user %d: %v", user.ID, err)
+		}
+	}
+
 	return &user, nil
 }
 
@@ -90,13 +160,86 @@ func (r *Repo) GetUserByEmail(email string) (*response.User, error) {
 	}, nil
 }
 
-func (r *Repo) InsertOtp(data domain.User) error {
+// GetUserByIdentifier fetches a user by email or phone
+func (r *Repo) GetUserByIdentifier(identifier string) (*domain.User, error) {
+	var user domain.User
+	if identifier == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Where("email = ? OR phone = ?", identifier, identifier).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
 
-	err := r.db.Save(&data).Error
+// GetUserByID fetches user by primary key
+func (r *Repo) GetUserByID(id int) (*domain.User, error) {
+	var user domain.User
+	err := r.db.First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// UpdatePassword hashes and stores a new password for the user
+func (r *Repo) UpdatePassword(userID int, password string) error {
+	hashed, err := r.hasher.Hash(password)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("password", hashed).Error
+}
 
+func (r *Repo) CreateUser(user *domain.User) error {
+	if user.Role == "" {
+		user.Role = domain.RoleUser
+	}
+	return r.db.Create(user).Error
+}
+
+// ClaimUnverifiedUser hands an unverified account to whoever just proved they
+// own its address through another route than the signup code. Everything the
+// person who signed up could have set is removed first: the password, any
+// sessions, refresh and access tokens, two-factor setup and linked
+// providers. Nothing happens if the account was verified in the meantime.
+func (r *Repo) ClaimUnverifiedUser(userID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.User{}).
+			Where("id = ? AND is_verified = ?", userID, false).
+			Updates(map[string]any{"is_verified": true, "password": ""})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+
+		now := time.Now()
+		for _, model := range []any{&domain.Session{}, &domain.RefreshToken{}, &domain.PersonalAccessToken{}} {
+			err := tx.Model(model).
+				Where("user_id = ? AND revoked_at IS NULL", userID).
+				Update("revoked_at", now).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []any{&domain.TwoFactor{}, &domain.RecoveryCode{}, &domain.ExternalIdentity{}} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// MarkVerified marks the user as verified by ID
+func (r *Repo) MarkVerified(userID int) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("is_verified", true).Error
 }
@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+func (r *Repo) CreateDataExport(export *domain.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+func (r *Repo) SaveDataExport(export *domain.DataExport) error {
+	return r.db.Save(export).Error
+}
+
+func (r *Repo) GetDataExport(userID, id int) (*domain.DataExport, error) {
+	var export domain.DataExport
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+func (r *Repo) ListDataExports(userID int) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := r.db.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&exports).Error
+
+	return exports, err
+}
+
+// ListPendingDataExports returns exports that have not been built yet,
+// including any interrupted by a restart.
+func (r *Repo) ListPendingDataExports() ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := r.db.Where("status = ?", domain.ExportPending).Order("id").Find(&exports).Error
+	return exports, err
+}
+
+// ListExpiredDataExports returns exports whose archive should be removed
+func (r *Repo) ListExpiredDataExports(now time.Time) ([]domain.DataExport, error) {
+	var exports []domain.DataExport
+	err := r.db.Where("expires_at < ?", now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *Repo) DeleteDataExport(id int) error {
+	return r.db.Delete(&domain.DataExport{}, id).Error
+}
+
+// ListAllSessions returns every session the user has had, newest first
+func (r *Repo) ListAllSessions(userID int) ([]domain.Session, error) {
+	var sessions []domain.Session
+	err := r.db.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
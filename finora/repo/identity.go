@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repo) CreateOAuthState(state *domain.OAuthState) error {
+	return r.db.Create(state).Error
+}
+
+// ConsumeOAuthState deletes and returns the pending authorization request for
+// state. Concurrent callers cannot both get it.
+func (r *Repo) ConsumeOAuthState(state string) (*domain.OAuthState, error) {
+	var pending domain.OAuthState
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("state = ?", state).First(&pending).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&pending).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pending, nil
+}
+
+func (r *Repo) GetExternalIdentity(provider, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *Repo) ListExternalIdentities(userID int) ([]domain.ExternalIdentity, error) {
+	var identities []domain.ExternalIdentity
+	err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error
+
+	return identities, err
+}
+
+func (r *Repo) CreateExternalIdentity(identity *domain.ExternalIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// DeleteExternalIdentity detaches a provider from the user. It reports false
+// if the provider was not linked.
+func (r *Repo) DeleteExternalIdentity(userID int, provider string) (bool, error) {
+	res := r.db.Where("user_id = ? AND provider = ?", userID, provider).Delete(&domain.ExternalIdentity{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected > 0, nil
+}
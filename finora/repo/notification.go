@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repo) CreateNotification(notification *domain.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// ListNotifications returns up to limit of the user's notifications, newest
+// first, starting after the one with id beforeID when it is not 0. Type and
+// unread narrow the list when set.
+func (r *Repo) ListNotifications(userID, beforeID, limit int, kind string, unread bool) ([]domain.Notification, error) {
+	db := r.db.Where("user_id = ?", userID)
+	if beforeID > 0 {
+		db = db.Where("id < ?", beforeID)
+	}
+	if kind != "" {
+		db = db.Where("type = ?", kind)
+	}
+	if unread {
+		db = db.Where("read_at IS NULL")
+	}
+
+	var notifications []domain.Notification
+	err := db.Order("id DESC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *Repo) CountUnreadNotifications(userID int) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkNotificationRead marks one of the user's notifications read, keeping
+// the original time if it already was. It reports false if the user has no
+// such notification.
+func (r *Repo) MarkNotificationRead(userID, id int) (bool, error) {
+	res := r.db.Model(&domain.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	return res.RowsAffected == 1, res.Error
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user read
+// and reports how many there were.
+func (r *Repo) MarkAllNotificationsRead(userID int) (int64, error) {
+	res := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// DeleteNotification reports false if the user has no such notification
+func (r *Repo) DeleteNotification(userID, id int) (bool, error) {
+	res := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Notification{})
+	return res.RowsAffected == 1, res.Error
+}
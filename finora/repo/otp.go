@@ -0,0 +1,135 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repo) CreateOtp(otp *domain.Otp) error {
+	return r.db.Create(otp).Error
+}
+
+func (r *Repo) GetOtpByID(id int) (*domain.Otp, error) {
+	var otp domain.Otp
+	err := r.db.First(&otp, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp, nil
+}
+
+// GetActiveOtp returns the most recent unconsumed OTP for the identifier and purpose
+func (r *Repo) GetActiveOtp(identifier, purpose string) (*domain.Otp, error) {
+	var otp domain.Otp
+	err := r.db.
+		Where("identifier = ? AND purpose = ? AND consumed = ?", identifier, purpose, false).
+		Order("created_at DESC").
+		First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp, nil
+}
+
+// GetActiveUserOtp is GetActiveOtp limited to codes issued to the user, for
+// identifiers that several accounts may be trying to claim at once
+func (r *Repo) GetActiveUserOtp(userID int, identifier, purpose string) (*domain.Otp, error) {
+	var otp domain.Otp
+	err := r.db.
+		Where("user_id = ? AND identifier = ? AND purpose = ? AND consumed = ?", userID, identifier, purpose, false).
+		Order("created_at DESC").
+		First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp, nil
+}
+
+// IncrementOtpAttempts records a verification attempt. It reports false when
+// the OTP has already used up maxAttempts.
+func (r *Repo) IncrementOtpAttempts(id int, maxAttempts int) (bool, error) {
+	res := r.db.Model(&domain.Otp{}).
+		Where("id = ? AND attempts < ?", id, maxAttempts).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// ConsumeOtp marks the OTP as used. It reports false if another request
+// consumed it first.
+func (r *Repo) ConsumeOtp(id int) (bool, error) {
+	res := r.db.Model(&domain.Otp{}).
+		Where("id = ? AND consumed = ?", id, false).
+		Update("consumed", true)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// InvalidateOtps consumes every outstanding OTP for the identifier and purpose
+func (r *Repo) InvalidateOtps(identifier, purpose string) error {
+	return r.db.Model(&domain.Otp{}).
+		Where("identifier = ? AND purpose = ? AND consumed = ?", identifier, purpose, false).
+		Update("consumed", true).Error
+}
+
+// InvalidateUserOtps is InvalidateOtps limited to codes issued to the user
+func (r *Repo) InvalidateUserOtps(userID int, identifier, purpose string) error {
+	return r.db.Model(&domain.Otp{}).
+		Where("user_id = ? AND identifier = ? AND purpose = ? AND consumed = ?", userID, identifier, purpose, false).
+		Update("consumed", true).Error
+}
+
+// GetLatestOtp returns the most recently issued OTP for the identifier, consumed or not
+func (r *Repo) GetLatestOtp(identifier, purpose string) (*domain.Otp, error) {
+	var otp domain.Otp
+	err := r.db.
+		Where("identifier = ? AND purpose = ?", identifier, purpose).
+		Order("created_at DESC").
+		First(&otp).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp, nil
+}
+
+// CountOtpsByIdentifier returns how many OTPs were sent to the identifier since
+// the given time, along with the time of the oldest of them.
+func (r *Repo) CountOtpsByIdentifier(identifier string, since time.Time) (int64, time.Time, error) {
+	return r.countOtpsSince(r.db.Where("identifier = ?", identifier), since)
+}
+
+// CountOtpsByIP returns how many OTPs were requested from the IP since the
+// given time, along with the time of the oldest of them.
+func (r *Repo) CountOtpsByIP(ip string, since time.Time) (int64, time.Time, error) {
+	return r.countOtpsSince(r.db.Where("request_ip = ?", ip), since)
+}
+
+// countOtpsSince reads the oldest OTP as a row rather than MIN(created_at),
+// which not every driver returns as a time.
+func (r *Repo) countOtpsSince(query *gorm.DB, since time.Time) (int64, time.Time, error) {
+	query = query.Model(&domain.Otp{}).Where("created_at > ?", since).Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil || count == 0 {
+		return count, time.Time{}, err
+	}
+
+	var oldest domain.Otp
+	if err := query.Select("created_at").Order("created_at").First(&oldest).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+
+	return count, oldest.CreatedAt, nil
+}
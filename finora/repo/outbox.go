@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repo) CreateOutboxEmail(email *domain.OutboxEmail) error {
+	return r.db.Create(email).Error
+}
+
+// ClaimOutboxEmail picks the pending email that is due soonest and leases it
+// until now+lease under a fresh token, so that another dispatcher does not
+// pick it up while it is being sent. Each claim counts as an attempt. It
+// returns nil when nothing is due.
+func (r *Repo) ClaimOutboxEmail(now time.Time, lease time.Duration) (*domain.OutboxEmail, error) {
+	var email domain.OutboxEmail
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", domain.EmailPending, now).
+			Order("next_attempt_at").
+			First(&email).Error
+		if err != nil {
+			return err
+		}
+
+		email.Attempts++
+		email.NextAttemptAt = now.Add(lease)
+		email.LeaseToken = utils.RandomID()
+		return tx.Model(&domain.OutboxEmail{}).
+			Where("id = ?", email.ID).
+			Updates(map[string]any{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": email.NextAttemptAt,
+				"lease_token":     email.LeaseToken,
+			}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// MarkOutboxEmailSent records a delivery and drops the body, which may hold
+// one-time codes. It reports false if the lease was lost to another
+// dispatcher in the meantime.
+func (r *Repo) MarkOutboxEmailSent(id int, lease string) (bool, error) {
+	return r.updateLeasedOutboxEmail(id, lease, map[string]any{
+		"status":     domain.EmailSent,
+		"sent_at":    time.Now(),
+		"html":       "",
+		"text":       "",
+		"last_error": "",
+	})
+}
+
+// MarkOutboxEmailFailed schedules another attempt at next. It reports false
+// if the lease was lost.
+func (r *Repo) MarkOutboxEmailFailed(id int, lease, sendErr string, next time.Time) (bool, error) {
+	return r.updateLeasedOutboxEmail(id, lease, map[string]any{
+		"last_error":      sendErr,
+		"next_attempt_at": next,
+	})
+}
+
+// MarkOutboxEmailDead moves the email to the dead letters. With dropBody the
+// body is cleared too, for email that is no use to anyone any more. It
+// reports false if the lease was lost.
+func (r *Repo) MarkOutboxEmailDead(id int, lease, reason string, dropBody bool) (bool, error) {
+	updates := map[string]any{
+		"status":     domain.EmailDead,
+		"last_error": reason,
+	}
+	if dropBody {
+		updates["html"], updates["text"] = "", ""
+	}
+	return r.updateLeasedOutboxEmail(id, lease, updates)
+}
+
+func (r *Repo) updateLeasedOutboxEmail(id int, lease string, updates map[string]any) (bool, error) {
+	updates["lease_token"] = ""
+	res := r.db.Model(&domain.OutboxEmail{}).
+		Where("id = ? AND status = ? AND lease_token = ?", id, domain.EmailPending, lease).
+		Updates(updates)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// DropOutboxEmailBodies clears the bodies of emails in status made from one
+// of templates and queued before the given time
+func (r *Repo) DropOutboxEmailBodies(status string, templates []string, before time.Time) (int64, error) {
+	res := r.db.Model(&domain.OutboxEmail{}).
+		Where("status = ? AND template IN ? AND created_at < ? AND (html <> '' OR text <> '')", status, templates, before).
+		Updates(map[string]any{"html": "", "text": ""})
+
+	return res.RowsAffected, res.Error
+}
+
+// GetOutboxEmail fetches a queued, sent or dead email by id
+func (r *Repo) GetOutboxEmail(id int) (*domain.OutboxEmail, error) {
+	var email domain.OutboxEmail
+	if err := r.db.First(&email, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// ListOutboxEmails returns emails in the given status, or all of them when
+// status is empty, newest first
+func (r *Repo) ListOutboxEmails(status string, limit, offset int) ([]domain.OutboxEmail, int64, error) {
+	query := r.db.Model(&domain.OutboxEmail{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var emails []domain.OutboxEmail
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&emails).Error
+	return emails, total, err
+}
+
+// RetryOutboxEmail puts a dead email back in the queue with a fresh attempt
+// count. It reports false if there is no dead email with that id.
+func (r *Repo) RetryOutboxEmail(id int) (bool, error) {
+	res := r.db.Model(&domain.OutboxEmail{}).
+		Where("id = ? AND status = ?", id, domain.EmailDead).
+		Updates(map[string]any{
+			"status":          domain.EmailPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// PurgeSentOutboxEmails deletes emails sent before the given time
+func (r *Repo) PurgeSentOutboxEmails(before time.Time) (int64, error) {
+	res := r.db.
+		Where("status = ? AND sent_at < ?", domain.EmailSent, before).
+		Delete(&domain.OutboxEmail{})
+
+	return res.RowsAffected, res.Error
+}
+
+// PurgeDeadOutboxEmails deletes emails dead-lettered before the given time,
+// bodies and all
+func (r *Repo) PurgeDeadOutboxEmails(before time.Time) (int64, error) {
+	res := r.db.
+		Where("status = ? AND updated_at < ?", domain.EmailDead, before).
+		Delete(&domain.OutboxEmail{})
+
+	return res.RowsAffected, res.Error
+}
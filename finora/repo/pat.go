@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+func (r *Repo) CreatePAT(token *domain.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *Repo) GetPATByHash(hash string) (*domain.PersonalAccessToken, error) {
+	var token domain.PersonalAccessToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// ListPATs returns the user's tokens that have not been revoked
+func (r *Repo) ListPATs(userID int) ([]domain.PersonalAccessToken, error) {
+	var tokens []domain.PersonalAccessToken
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+
+	return tokens, err
+}
+
+// RevokePAT revokes one of the user's tokens. It reports false if no such
+// active token exists.
+func (r *Repo) RevokePAT(userID, id int) (bool, error) {
+	res := r.db.Model(&domain.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+func (r *Repo) TouchPAT(id int) error {
+	return r.db.Model(&domain.PersonalAccessToken{}).
+		Where("id = ?", id).
+		Update("last_used_at", time.Now()).Error
+}
@@ -0,0 +1,57 @@
+package repo
+
+import (
+	"errors"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/hasher"
+)
+
+func (r *Repo) UpdateUsername(userID int, username string) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("username", username).Error
+}
+
+func (r *Repo) UpdateLocale(userID int, locale string) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("locale", locale).Error
+}
+
+func (r *Repo) UpdateEmail(userID int, email string) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("email", email).Error
+}
+
+func (r *Repo) UpdatePhone(userID int, phone string) error {
+	return r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("phone", phone).Error
+}
+
+// IdentifierTaken reports whether an email or phone belongs to a user other
+// than userID.
+func (r *Repo) IdentifierTaken(identifier string, userID int) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.User{}).
+		Where("(email = ? OR phone = ?) AND id <> ?", identifier, identifier, userID).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+// CheckPassword verifies the user's current password. Accounts without a
+// password, such as those created through social login, never match.
+func (r *Repo) CheckPassword(user *domain.User, password string) (bool, error) {
+	if user.Password == "" {
+		return false, nil
+	}
+
+	ok, err := r.hasher.Verify(user.Password, password)
+	if errors.Is(err, hasher.ErrUnknownHash) {
+		return false, nil
+	}
+	return ok, err
+}
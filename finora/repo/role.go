@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm/clause"
+)
+
+// LockAdminBootstrap holds a lock until the end of the current transaction so
+// that instances starting together do not each promote an admin.
+func (r *Repo) LockAdminBootstrap() error {
+	return r.db.Exec("SELECT pg_advisory_xact_lock(hashtext('finora.bootstrap_admin'))").Error
+}
+
+func (r *Repo) CountUsersByRole(role string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+// LockAdmins locks every admin row until the end of the current transaction
+// and returns how many there are. A concurrent role change waits for the lock
+// and then counts the admins that are left.
+func (r *Repo) LockAdmins() (int, error) {
+	var ids []int
+	err := r.db.Model(&domain.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ?", domain.RoleAdmin).
+		Pluck("id", &ids).Error
+	return len(ids), err
+}
+
+// SetUserRole changes the user's role. It reports false if the user does not
+// exist.
+func (r *Repo) SetUserRole(userID int, role string) (bool, error) {
+	res := r.db.Model(&domain.User{}).
+		Where("id = ?", userID).
+		Update("role", role)
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repo) CreateSession(session *domain.Session) error {
+	return r.db.Create(session).Error
+}
+
+func (r *Repo) GetSession(id int) (*domain.Session, error) {
+	var session domain.Session
+	err := r.db.First(&session, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// MarkSessionAuthenticated records that the user just proved who they are on
+// the session
+func (r *Repo) MarkSessionAuthenticated(id int) error {
+	return r.db.Model(&domain.Session{}).
+		Where("id = ?", id).
+		Update("authenticated_at", time.Now()).Error
+}
+
+// TouchSession records activity on a session
+func (r *Repo) TouchSession(id int, ip string) error {
+	return r.db.Model(&domain.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": time.Now(), "ip": ip}).Error
+}
+
+// ListActiveSessions returns the user's sessions that have not been revoked
+func (r *Repo) ListActiveSessions(userID int) ([]domain.Session, error) {
+	var sessions []domain.Session
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+
+	return sessions, err
+}
+
+// RevokeSession revokes one of the user's sessions and its refresh tokens. It
+// reports false if no such active session exists.
+func (r *Repo) RevokeSession(userID, sessionID int) (bool, error) {
+	var revoked bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&domain.Session{}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+			Update("revoked_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		revoked = res.RowsAffected == 1
+
+		return tx.Model(&domain.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+	})
+
+	return revoked, err
+}
+
+// RevokeAllSessions revokes every session of the user except the one given
+// in keep, which may be 0 to revoke them all.
+func (r *Repo) RevokeAllSessions(userID, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&domain.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keep).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keep).
+			Update("revoked_at", now).Error
+	})
+}
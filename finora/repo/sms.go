@@ -0,0 +1,29 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+func (r *Repo) CreateSMSMessage(msg *domain.SMSMessage) error {
+	return r.db.Create(msg).Error
+}
+
+// CountSMSMessages returns how many text messages went to the number since
+// the given time, along with the time of the oldest of them.
+func (r *Repo) CountSMSMessages(recipient string, since time.Time) (int64, time.Time, error) {
+	var stats struct {
+		Count  int64
+		Oldest *time.Time
+	}
+	err := r.db.Model(&domain.SMSMessage{}).
+		Select("COUNT(*) AS count, MIN(created_at) AS oldest").
+		Where("recipient = ? AND created_at > ?", recipient, since).
+		Scan(&stats).Error
+	if err != nil || stats.Oldest == nil {
+		return stats.Count, time.Time{}, err
+	}
+
+	return stats.Count, *stats.Oldest, nil
+}
@@ -0,0 +1,93 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *Repo) GetLoginThrottle(key string) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	err := r.db.Where("key = ?", key).First(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &throttle, nil
+}
+
+// RecordLoginFailure adds a failure to the key's streak. When the streak
+// reaches lockAfter the key is locked for lockFor(lock count) and the streak
+// starts over; locked reports whether this call caused the lock.
+func (r *Repo) RecordLoginFailure(key string, lockAfter int, lockFor func(lockCount int) time.Duration) (throttle *domain.LoginThrottle, locked bool, err error) {
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		throttle = &domain.LoginThrottle{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(throttle).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			throttle = &domain.LoginThrottle{Key: key}
+		} else if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		throttle.Failures++
+		throttle.TotalFailures++
+		throttle.LastFailureAt = &now
+
+		if throttle.Failures >= lockAfter {
+			until := now.Add(lockFor(throttle.LockCount))
+			throttle.LockedUntil = &until
+			throttle.LockCount++
+			throttle.Failures = 0
+			throttle.UnlockJTI = ""
+			locked = true
+		}
+
+		return tx.Save(throttle).Error
+	})
+
+	return throttle, locked, err
+}
+
+// SetUnlockJTI remembers the unlock link issued for a locked key
+func (r *Repo) SetUnlockJTI(key, jti string) error {
+	return r.db.Model(&domain.LoginThrottle{}).
+		Where("key = ?", key).
+		Update("unlock_jti", jti).Error
+}
+
+// ResetLoginThrottle clears the failure streak and any lock on the key. The
+// lifetime counters are kept.
+func (r *Repo) ResetLoginThrottle(key string) error {
+	return r.db.Model(&domain.LoginThrottle{}).
+		Where("key = ?", key).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": nil, "last_failure_at": nil, "unlock_jti": ""}).Error
+}
+
+// UnlockLoginThrottle clears the lock on key if jti matches the unlock link
+// that was sent. It reports false otherwise.
+func (r *Repo) UnlockLoginThrottle(key, jti string) (bool, error) {
+	res := r.db.Model(&domain.LoginThrottle{}).
+		Where("key = ? AND unlock_jti = ? AND unlock_jti <> ''", key, jti).
+		Updates(map[string]interface{}{"failures": 0, "locked_until": nil, "last_failure_at": nil, "unlock_jti": ""})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// ListLoginThrottles returns the keys with the most failures first
+func (r *Repo) ListLoginThrottles(limit, offset int) ([]domain.LoginThrottle, error) {
+	var throttles []domain.LoginThrottle
+	err := r.db.
+		Order("total_failures DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&throttles).Error
+
+	return throttles, err
+}
@@ -0,0 +1,41 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+func (r *Repo) CreateRefreshToken(token *domain.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *Repo) GetRefreshToken(jti string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := r.db.Where("jti = ?", jti).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed flags the token as exchanged. It reports false when the
+// token was already used or revoked, which means it is being replayed.
+func (r *Repo) MarkRefreshTokenUsed(jti string) (bool, error) {
+	res := r.db.Model(&domain.RefreshToken{}).
+		Where("jti = ? AND used_at IS NULL AND revoked_at IS NULL", jti).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// RevokeRefreshFamily revokes every token rotated from the same login
+func (r *Repo) RevokeRefreshFamily(family string) error {
+	return r.db.Model(&domain.RefreshToken{}).
+		Where("family = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", time.Now()).Error
+}
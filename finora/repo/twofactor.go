@@ -0,0 +1,130 @@
+package repo
+
+import (
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+)
+
+func (r *Repo) GetTwoFactor(userID int) (*domain.TwoFactor, error) {
+	var tf domain.TwoFactor
+	err := r.db.Where("user_id = ?", userID).First(&tf).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &tf, nil
+}
+
+func (r *Repo) SaveTwoFactor(tf *domain.TwoFactor) error {
+	return r.db.Save(tf).Error
+}
+
+// EnableTwoFactor turns on 2FA for the user and replaces any recovery codes
+func (r *Repo) EnableTwoFactor(userID int, step int64, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Model(&domain.TwoFactor{}).
+			Where("user_id = ?", userID).
+			Updates(map[string]interface{}{"enabled": true, "confirmed_at": now, "last_used_step": step}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		codes := make([]domain.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, domain.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// DeleteTwoFactor removes the user's TOTP secret and recovery codes
+func (r *Repo) DeleteTwoFactor(userID int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.TwoFactor{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&domain.RecoveryCode{}).Error
+	})
+}
+
+// UseTotpStep records the time step of an accepted code. It reports false if
+// that step, or a later one, was already used.
+func (r *Repo) UseTotpStep(userID int, step int64) (bool, error) {
+	res := r.db.Model(&domain.TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{"last_used_step": step, "failed_attempts": 0, "locked_until": nil})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// UseRecoveryCode consumes a recovery code. It reports false if the code does
+// not exist or was already used.
+func (r *Repo) UseRecoveryCode(userID int, codeHash string) (bool, error) {
+	res := r.db.Model(&domain.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
+
+// RecordTwoFactorFailure counts a wrong code and locks 2FA verification once
+// maxAttempts is reached.
+func (r *Repo) RecordTwoFactorFailure(userID int, maxAttempts int, lockFor time.Duration) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var tf domain.TwoFactor
+		if err := tx.Where("user_id = ?", userID).First(&tf).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"failed_attempts": tf.FailedAttempts + 1}
+		if tf.FailedAttempts+1 >= maxAttempts {
+			updates["failed_attempts"] = 0
+			updates["locked_until"] = time.Now().Add(lockFor)
+		}
+		return tx.Model(&tf).Updates(updates).Error
+	})
+}
+
+// CreateMFAChallenge records a pending two-factor login and clears out the
+// ones that expired without being completed
+func (r *Repo) CreateMFAChallenge(challenge *domain.MFAChallenge) error {
+	if err := r.db.Where("expires_at < ?", time.Now()).Delete(&domain.MFAChallenge{}).Error; err != nil {
+		return err
+	}
+	return r.db.Create(challenge).Error
+}
+
+// GetMFAChallenge returns the pending two-factor login for jti
+func (r *Repo) GetMFAChallenge(jti string) (*domain.MFAChallenge, error) {
+	var challenge domain.MFAChallenge
+	err := r.db.Where("jti = ?", jti).First(&challenge).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// ConsumeMFAChallenge deletes the pending two-factor login for jti. It
+// reports false if another request completed it first.
+func (r *Repo) ConsumeMFAChallenge(jti string) (bool, error) {
+	res := r.db.Where("jti = ?", jti).Delete(&domain.MFAChallenge{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	return res.RowsAffected == 1, nil
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountRoutes cover exporting the user's data and deleting the account.
+// Both need a signed-in session.
+func AccountRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	accountGroup := router.Group("/me", auth, middleware.RequireSession())
+	{
+		accountGroup.POST("/exports", handler.RequestExport)
+		accountGroup.GET("/exports", handler.ListExports)
+		accountGroup.GET("/exports/:id", handler.GetExport)
+		accountGroup.GET("/exports/:id/download", handler.DownloadExport)
+		accountGroup.DELETE("", handler.DeleteAccount)
+		accountGroup.POST("/deletion/cancel", handler.CancelAccountDeletion)
+	}
+}
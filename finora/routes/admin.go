@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminRoutes are only reachable from a signed-in session, and each route
+// declares the permission it needs.
+func AdminRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	adminGroup := router.Group("/admin", auth, middleware.RequireSession())
+	{
+		adminGroup.GET("/login-throttles", middleware.RequirePermission(domain.PermSecurityRead), handler.LoginThrottles)
+		adminGroup.PUT("/users/:id/role", middleware.RequirePermission(domain.PermRolesManage), handler.SetUserRole)
+		adminGroup.GET("/audit-events", middleware.RequirePermission(domain.PermAuditRead), handler.AuditEvents)
+		adminGroup.GET("/emails", middleware.RequirePermission(domain.PermEmailsManage), handler.OutboxEmails)
+		adminGroup.POST("/emails/:id/retry", middleware.RequirePermission(domain.PermEmailsManage), handler.RetryEmail)
+		adminGroup.GET("/email-templates", middleware.RequirePermission(domain.PermEmailsManage), handler.EmailTemplates)
+		adminGroup.GET("/email-templates/:name/preview", middleware.RequirePermission(domain.PermEmailsManage), handler.PreviewEmailTemplate)
+	}
+
+	readUsers := middleware.RequirePermission(domain.PermUsersRead)
+	manageUsers := middleware.RequirePermission(domain.PermUsersManage)
+	userGroup := adminGroup.Group("/users")
+	{
+		userGroup.GET("", readUsers, handler.AdminSearchUsers)
+		userGroup.GET("/:id", readUsers, handler.AdminGetUser)
+		userGroup.POST("/:id/verify", manageUsers, handler.AdminVerifyUser)
+		userGroup.POST("/:id/disable", manageUsers, handler.AdminDisableUser)
+		userGroup.POST("/:id/enable", manageUsers, handler.AdminEnableUser)
+		userGroup.POST("/:id/2fa/reset", manageUsers, handler.AdminResetTwoFactor)
+		userGroup.POST("/:id/sessions/revoke", manageUsers, handler.AdminRevokeSessions)
+		userGroup.POST("/:id/resend-verification", manageUsers, handler.AdminResendVerification)
+	}
+}
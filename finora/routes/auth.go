@@ -2,17 +2,31 @@ package routes
 
 import (
 	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthRoutes(router *gin.Engine, handler *handler.Handler) {
+func AuthRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/signup", handler.Signup)
 		authGroup.POST("/login", handler.Login)
-		// router.POST("/resend-otp", handler.ResendOTP)
-		// router.POST("/verify-otp", handler.VerifyOTP)
+		authGroup.POST("/login/mfa", handler.VerifyMFA)
+		authGroup.POST("/refresh", handler.Refresh)
+		authGroup.POST("/logout", auth, middleware.RequireSession(), handler.Logout)
+		authGroup.POST("/forgot-password", handler.ForgotPassword)
+		authGroup.POST("/reset-password", handler.ResetPassword)
+		authGroup.GET("/unlock", handler.UnlockAccount)
+		authGroup.POST("/verify-otp", handler.VerifyOTP)
+		authGroup.POST("/resend-otp", handler.ResendOTP)
 
 
 	}
+
+	// Passwordless login, as documented in the API collection
+	apiAuthGroup := router.Group("/api/auth")
+	{
+		apiAuthGroup.POST("/send-otp", handler.SendLoginOTP)
+		apiAuthGroup.POST("/verify-otp", handler.VerifyLoginOTP)
+	}
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationRoutes let a signed-in user read and manage their in-app
+// notifications.
+func NotificationRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	notificationGroup := router.Group("/api/notifications", auth, middleware.RequireSession())
+	{
+		notificationGroup.GET("", handler.ListNotifications)
+		notificationGroup.GET("/unread-count", handler.UnreadNotificationCount)
+		notificationGroup.POST("/read-all", handler.MarkAllNotificationsRead)
+		notificationGroup.POST("/:id/read", handler.MarkNotificationRead)
+		notificationGroup.DELETE("/:id", handler.DeleteNotification)
+	}
+}
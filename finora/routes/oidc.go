@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func OIDCRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	oidcGroup := router.Group("/auth/oidc")
+	{
+		oidcGroup.GET("/providers", handler.OIDCProviders)
+		oidcGroup.GET("/:provider/login", handler.OIDCLogin)
+		oidcGroup.GET("/:provider/callback", handler.OIDCCallback)
+	}
+
+	identityGroup := router.Group("/me/identities", auth, middleware.RequireSession())
+	{
+		identityGroup.GET("", handler.ListIdentities)
+		identityGroup.POST("/:provider", handler.LinkIdentity)
+		identityGroup.DELETE("/:provider", handler.UnlinkIdentity)
+	}
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// PATRoutes manage personal access tokens. A token cannot be used to mint or
+// revoke tokens, so these routes need a signed-in session.
+func PATRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	patGroup := router.Group("/pats", auth, middleware.RequireSession())
+	{
+		patGroup.POST("", handler.CreatePAT)
+		patGroup.GET("", handler.ListPATs)
+		patGroup.DELETE("/:id", handler.RevokePAT)
+	}
+}
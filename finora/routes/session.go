@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func SessionRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	sessionGroup := router.Group("/sessions", auth, middleware.RequireSession())
+	{
+		sessionGroup.GET("", handler.ListSessions)
+		sessionGroup.DELETE("/:id", handler.RevokeSession)
+		sessionGroup.POST("/logout-all", handler.LogoutAll)
+	}
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func TwoFactorRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	twoFactorGroup := router.Group("/2fa", auth, middleware.RequireSession())
+	{
+		twoFactorGroup.POST("/enroll", handler.EnrollTwoFactor)
+		twoFactorGroup.POST("/confirm", handler.ConfirmTwoFactor)
+		twoFactorGroup.POST("/disable", handler.DisableTwoFactor)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/ayyoob-k-a/finora/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+func UserRoutes(router *gin.Engine, handler *handler.Handler, auth gin.HandlerFunc) {
+	meGroup := router.Group("/me", auth)
+	{
+		meGroup.GET("", middleware.RequireScope(domain.ScopeProfileRead), handler.Me)
+		meGroup.PATCH("", middleware.RequireSession(), handler.UpdateProfile)
+		meGroup.POST("/contact/confirm", middleware.RequireSession(), handler.ConfirmContactChange)
+		meGroup.POST("/password", middleware.RequireSession(), handler.ChangePassword)
+		meGroup.POST("/reauth", middleware.RequireSession(), handler.Reauthenticate)
+		meGroup.POST("/reauth/otp", middleware.RequireSession(), handler.SendReauthOTP)
+		meGroup.GET("/security-events", middleware.RequireSession(), handler.SecurityEvents)
+	}
+}
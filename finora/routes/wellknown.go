@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/ayyoob-k-a/finora/handler"
+	"github.com/gin-gonic/gin"
+)
+
+func WellKnownRoutes(router *gin.Engine, handler *handler.Handler) {
+	router.GET("/.well-known/jwks.json", handler.JWKS)
+}
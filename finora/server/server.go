@@ -2,11 +2,16 @@ package server
 
 import "github.com/gin-gonic/gin"
 
-func InitRouter() *gin.Engine {
+// InitRouter builds the engine. Only the proxies in trustedProxies may set
+// the client address through X-Forwarded-For; with none, c.ClientIP() is the
+// address of the connection and cannot be chosen by the client.
+func InitRouter(trustedProxies []string) (*gin.Engine, error) {
 	router := gin.Default()
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
 
-
-	return router
+	return router, nil
 }
 func StartServer(router *gin.Engine) {
 	// Start the server on port 8080
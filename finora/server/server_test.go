@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientIPIgnoresForgedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		proxies      []string
+		remoteAddr   string
+		forwardedFor string
+		realIP       string
+		wantClientIP string
+	}{
+		{
+			name:         "direct client forging X-Forwarded-For",
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: "198.51.100.1",
+			wantClientIP: "203.0.113.7",
+		},
+		{
+			name:         "direct client forging X-Real-IP",
+			remoteAddr:   "203.0.113.7:51234",
+			realIP:       "198.51.100.1",
+			wantClientIP: "203.0.113.7",
+		},
+		{
+			name:         "untrusted proxy",
+			proxies:      []string{"10.0.0.0/8"},
+			remoteAddr:   "203.0.113.7:51234",
+			forwardedFor: "198.51.100.1",
+			wantClientIP: "203.0.113.7",
+		},
+		{
+			name:         "trusted proxy",
+			proxies:      []string{"10.0.0.0/8"},
+			remoteAddr:   "10.0.0.2:51234",
+			forwardedFor: "198.51.100.1",
+			wantClientIP: "198.51.100.1",
+		},
+		{
+			name:         "client prepending to a trusted proxy's header",
+			proxies:      []string{"10.0.0.0/8"},
+			remoteAddr:   "10.0.0.2:51234",
+			forwardedFor: "192.0.2.99, 198.51.100.1",
+			wantClientIP: "198.51.100.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, err := InitRouter(tt.proxies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Body.String(); got != tt.wantClientIP {
+				t.Fatalf("client ip = %q, want %q", got, tt.wantClientIP)
+			}
+		})
+	}
+}
+
+func TestInitRouterRejectsInvalidProxy(t *testing.T) {
+	if _, err := InitRouter([]string{"not-an-address"}); err == nil {
+		t.Fatal("expected an invalid trusted proxy to be rejected")
+	}
+}
@@ -0,0 +1,100 @@
+package sms
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+)
+
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTP sends messages through an SMS gateway's HTTP API. Each message is
+// POSTed to URL as JSON {"from", "to", "body"}, authenticated with a bearer
+// token or basic auth. Any 2xx response counts as accepted.
+type HTTP struct {
+	client   *http.Client
+	url      string
+	from     string
+	token    string
+	username string
+	password string
+}
+
+func NewHTTP(cfg configs.SMS) (*HTTP, error) {
+	endpoint, err := url.Parse(cfg.HTTPURL)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid SMS_HTTP_URL %q", cfg.HTTPURL)
+	}
+	if endpoint.Scheme != "https" && !isLoopback(endpoint.Hostname()) {
+		return nil, errors.New("SMS_HTTP_URL must use https unless it points at localhost")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	return &HTTP{
+		client:   &http.Client{Timeout: timeout},
+		url:      endpoint.String(),
+		from:     cfg.From,
+		token:    cfg.HTTPToken,
+		username: cfg.HTTPUsername,
+		password: cfg.HTTPPassword,
+	}, nil
+}
+
+func (h *HTTP) Send(msg Message) error {
+	if msg.To == "" {
+		return ErrNoRecipient
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"from": h.from,
+		"to":   msg.To,
+		"body": msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case h.token != "":
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	case h.username != "":
+		req.SetBasicAuth(h.username, h.password)
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms gateway: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("sms gateway returned %s: %s", res.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
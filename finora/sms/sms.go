@@ -0,0 +1,88 @@
+// Package sms delivers text messages. The backend is chosen by
+// configuration: an HTTP gateway in production, or a stub that logs or keeps
+// messages in memory for development and tests.
+package sms
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ayyoob-k-a/finora/configs"
+)
+
+// SMS backends. An empty backend disables SMS.
+const (
+	BackendHTTP   = "http"
+	BackendLog    = "log"
+	BackendMemory = "memory"
+)
+
+var ErrNoRecipient = errors.New("sms has no recipient")
+
+// Message is one text message. To is an E.164 phone number.
+type Message struct {
+	To   string
+	Body string
+}
+
+// Sender sends text messages. Implementations must be safe for concurrent use.
+type Sender interface {
+	Send(msg Message) error
+}
+
+// New returns the sender selected by cfg.Backend, or nil when SMS is disabled
+func New(cfg configs.SMS) (Sender, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case BackendHTTP:
+		return NewHTTP(cfg)
+	case BackendLog:
+		return Log{}, nil
+	case BackendMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS_BACKEND %q", cfg.Backend)
+	}
+}
+
+// otpTexts are the one-time code messages by locale. They take the code and
+// its lifetime in minutes.
+var otpTexts = map[string]string{
+	"en": "%s is your Finora code. It expires in %d minutes. Do not share it with anyone.",
+	"hi": "%s आपका Finora कोड है। यह %d मिनट में समाप्त हो जाएगा। इसे किसी के साथ साझा न करें।",
+}
+
+// contactChangedTexts warn a phone number that the account's contact details
+// changed. They take the new, already masked, email or phone number.
+var contactChangedTexts = map[string]string{
+	"en": "The contact details on your Finora account were changed to %s. If this was not you, contact support right away.",
+	"hi": "आपके Finora खाते की संपर्क जानकारी बदलकर %s कर दी गई है। यदि यह आपने नहीं किया, तो तुरंत सहायता से संपर्क करें।",
+}
+
+// OTP builds the message carrying a one-time code, in the user's language
+// when there is a translation and in English otherwise
+func OTP(to, locale, code string, expiresInMinutes int) Message {
+	return Message{To: to, Body: fmt.Sprintf(localized(otpTexts, locale), code, expiresInMinutes)}
+}
+
+// ContactChanged builds the warning sent when the account's email or phone
+// number was changed. newValue should already be masked.
+func ContactChanged(to, locale, newValue string) Message {
+	return Message{To: to, Body: fmt.Sprintf(localized(contactChangedTexts, locale), newValue)}
+}
+
+// localized picks the text for locale, falling back to its language and then
+// to English.
+func localized(texts map[string]string, locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if text, ok := texts[locale]; ok {
+		return text
+	}
+	language, _, _ := strings.Cut(locale, "-")
+	if text, ok := texts[language]; ok {
+		return text
+	}
+	return texts["en"]
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+// deletionBatchSize limits how many accounts are anonymized per run
+const deletionBatchSize = 100
+
+// RequestAccountDeletion schedules the user's account to be deleted once the
+// grace period has passed. Until then the user can still sign in and cancel.
+// Accounts with a password must confirm it; accounts without one must have
+// signed in or re-authenticated on sessionID recently, see Reauthenticate.
+func (u *Usecase) RequestAccountDeletion(userID, sessionID int, password string, client inbound.Client) (*time.Time, error) {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletionScheduledAt != nil {
+		return user.DeletionScheduledAt, nil
+	}
+
+	if user.Password != "" {
+		if err := u.checkLoginThrottle(accountThrottleKey(user.ID), "account temporarily locked after too many failed attempts"); err != nil {
+			return nil, err
+		}
+		ok, err := u.repo.CheckPassword(user, password)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			u.recordLoginFailure(user, client)
+			return nil, ErrIncorrectPassword
+		}
+	} else if err := u.requireRecentAuth(sessionID); err != nil {
+		return nil, err
+	}
+
+	at := time.Now().Add(u.account.DeletionGrace)
+	if err := u.repo.ScheduleDeletion(user.ID, &at); err != nil {
+		return nil, err
+	}
+
+	u.auditSelf(user.ID, domain.AuditDeletionRequest, client, map[string]any{"scheduled_at": at})
+	return &at, nil
+}
+
+func (u *Usecase) CancelAccountDeletion(userID int, client inbound.Client) error {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.DeletionScheduledAt == nil {
+		return nil
+	}
+
+	if err := u.repo.ScheduleDeletion(user.ID, nil); err != nil {
+		return err
+	}
+
+	u.auditSelf(user.ID, domain.AuditDeletionCancel, client, nil)
+	return nil
+}
+
+// RunAccountJobs removes expired data exports and deletes accounts whose
+// grace period has passed, every JobInterval. It blocks, so run it in its own
+// goroutine.
+func (u *Usecase) RunAccountJobs() {
+	if u.account.JobInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(u.account.JobInterval)
+	defer ticker.Stop()
+	for {
+		u.purgeExpiredExports()
+		u.deleteDueAccounts()
+		<-ticker.C
+	}
+}
+
+func (u *Usecase) deleteDueAccounts() {
+	users, err := u.repo.ListUsersDueForDeletion(time.Now(), deletionBatchSize)
+	if err != nil {
+		log.Printf("failed to list accounts due for deletion: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		if err := u.deleteAccount(&user); err != nil {
+			log.Printf("failed to delete account %d: %v", user.ID, err)
+		}
+	}
+}
+
+// deleteAccount removes the user's export archives from disk before the rows
+// pointing at them go, then anonymizes the account.
+func (u *Usecase) deleteAccount(user *domain.User) error {
+	exports, err := u.repo.ListDataExports(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, record := range exports {
+		if record.FilePath == "" {
+			continue
+		}
+		if err := os.Remove(record.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := u.repo.AnonymizeUser(user, accountThrottleKey(user.ID)); err != nil {
+		return err
+	}
+
+	u.audit(domain.AuditEvent{
+		Action:     domain.AuditAccountDeleted,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   &user.ID,
+	}, inbound.Client{})
+	return nil
+}
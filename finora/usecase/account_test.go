@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"gorm.io/gorm"
+)
+
+func TestRequestAccountDeletionProof(t *testing.T) {
+	tests := []struct {
+		name          string
+		hasPassword   bool
+		password      string
+		authenticated time.Duration // how long ago the session signed in
+		wantErr       error
+	}{
+		{name: "correct password", hasPassword: true, password: testPassword, authenticated: time.Hour},
+		{name: "wrong password", hasPassword: true, password: "wrong horse battery staple", wantErr: ErrIncorrectPassword},
+		{name: "passwordless with a recent sign-in", authenticated: time.Minute},
+		{name: "passwordless with a stale session", authenticated: reauthWindow + time.Minute, wantErr: ErrReauthRequired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.createUser(t, "delete@example.com")
+			if !tt.hasPassword {
+				env.db.Model(user).Update("password", "")
+				user.Password = ""
+			}
+			session := env.newSession(t, user, time.Now().Add(-tt.authenticated))
+
+			at, err := env.RequestAccountDeletion(user.ID, session.ID, tt.password, client)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			var stored domain.User
+			env.db.First(&stored, user.ID)
+			if scheduled := stored.DeletionScheduledAt != nil; scheduled != (tt.wantErr == nil) || (at != nil) != scheduled {
+				t.Fatalf("deletion scheduled = %v, returned %v", scheduled, at)
+			}
+		})
+	}
+}
+
+func TestOnePendingExportPerUser(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "export@example.com")
+	other := env.createUser(t, "other@example.com")
+
+	for _, record := range []*domain.DataExport{
+		{UserID: user.ID, Status: domain.ExportReady},
+		{UserID: user.ID, Status: domain.ExportPending},
+		{UserID: other.ID, Status: domain.ExportPending},
+	} {
+		if err := env.repo.CreateDataExport(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// What a request racing past the in-progress check runs into
+	err := env.repo.CreateDataExport(&domain.DataExport{UserID: user.ID, Status: domain.ExportPending})
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("second pending export: error = %v, want ErrDuplicatedKey", err)
+	}
+	if _, err := env.RequestExport(user.ID, client); !errors.Is(err, ErrExportInProgress) {
+		t.Fatalf("error = %v, want ErrExportInProgress", err)
+	}
+}
+
+func TestAnonymizeUserClearsLoginThrottle(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "gone@example.com")
+	kept := env.createUser(t, "kept@example.com")
+	env.setThrottle(t, accountThrottleKey(user.ID), 2, time.Minute)
+	env.setThrottle(t, accountThrottleKey(kept.ID), 2, time.Minute)
+
+	if err := env.repo.AnonymizeUser(user, accountThrottleKey(user.ID)); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []string
+	env.db.Model(&domain.LoginThrottle{}).Pluck("key", &keys)
+	if len(keys) != 1 || keys[0] != accountThrottleKey(kept.ID) {
+		t.Fatalf("throttles left = %v, want only %s", keys, accountThrottleKey(kept.ID))
+	}
+}
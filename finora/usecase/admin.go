@@ -0,0 +1,245 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAccountDisabled = errors.New("account has been disabled")
+	ErrActOnSelf       = errors.New("admins cannot do this to their own account")
+	ErrAccountDeleted  = errors.New("account has been deleted")
+)
+
+// AdminSearchUsers finds users by email, phone or username
+func (u *Usecase) AdminSearchUsers(query string, limit, offset int) (*response.UserPage, error) {
+	users, total, err := u.repo.SearchUsers(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &response.UserPage{
+		Users:  make([]response.AdminUser, 0, len(users)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for i := range users {
+		page.Users = append(page.Users, toAdminUser(&users[i]))
+	}
+	return page, nil
+}
+
+// AdminGetUser returns a user together with their login status
+func (u *Usecase) AdminGetUser(userID int) (*response.AdminUser, error) {
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return nil, err
+	}
+	res := toAdminUser(user)
+
+	res.TwoFactorEnabled, err = u.twoFactorEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.repo.ListActiveSessions(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	res.ActiveSessions = len(sessions)
+
+	res.LastLoginAt, err = u.repo.LastSessionAt(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	throttle, err := u.repo.GetLoginThrottle(accountThrottleKey(user.ID))
+	switch {
+	case err == nil:
+		res.FailedLogins = throttle.Failures
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(time.Now()) {
+			res.LockedUntil = throttle.LockedUntil
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// AdminVerifyUser marks a user verified without an OTP
+func (u *Usecase) AdminVerifyUser(actorID, userID int, client inbound.Client) error {
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsVerified {
+		return ErrAlreadyVerified
+	}
+
+	if err := u.repo.MarkVerified(user.ID); err != nil {
+		return err
+	}
+	if user.Email != "" {
+		if err := u.repo.InvalidateOtps(user.Email, domain.OtpPurposeVerify); err != nil {
+			return err
+		}
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminVerifyUser, user.ID, client, nil)
+	return nil
+}
+
+// AdminDisableUser blocks the user from signing in and ends their sessions
+func (u *Usecase) AdminDisableUser(actorID, userID int, client inbound.Client) error {
+	if actorID == userID {
+		return ErrActOnSelf
+	}
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return err
+	}
+	if user.DisabledAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if _, err := u.repo.SetUserDisabled(user.ID, &now); err != nil {
+		return err
+	}
+	if err := u.repo.RevokeAllSessions(user.ID, 0); err != nil {
+		return err
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminDisableUser, user.ID, client, nil)
+	return nil
+}
+
+// AdminEnableUser lets a disabled user sign in again. Deleted accounts stay
+// disabled; enabling one fails with ErrAccountDeleted.
+func (u *Usecase) AdminEnableUser(actorID, userID int, client inbound.Client) error {
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return err
+	}
+	if user.AnonymizedAt != nil {
+		return ErrAccountDeleted
+	}
+	if user.DisabledAt == nil {
+		return nil
+	}
+
+	ok, err := u.repo.SetUserDisabled(user.ID, nil)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Anonymized since it was read
+		return ErrAccountDeleted
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminEnableUser, user.ID, client, nil)
+	return nil
+}
+
+// AdminResetTwoFactor removes the user's authenticator and recovery codes so
+// they can sign in with their password and enroll again.
+func (u *Usecase) AdminResetTwoFactor(actorID, userID int, client inbound.Client) error {
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := u.repo.GetTwoFactor(user.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+	if err := u.repo.DeleteTwoFactor(user.ID); err != nil {
+		return err
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminResetTwoFactor, user.ID, client, nil)
+	u.notifySecurity(user.ID, "Two-factor authentication reset", "An administrator turned off two-factor authentication for your account.",
+		map[string]any{"event": domain.AuditAdminResetTwoFactor})
+	return nil
+}
+
+func (u *Usecase) AdminRevokeSessions(actorID, userID int, client inbound.Client) error {
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.RevokeAllSessions(user.ID, 0); err != nil {
+		return err
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminRevokeSessions, user.ID, client, nil)
+	return nil
+}
+
+// AdminResendVerification sends a fresh verification code. Unlike ResendOTP it
+// is not subject to the per-identifier send limits.
+func (u *Usecase) AdminResendVerification(actorID, userID int, client inbound.Client) error {
+	user, err := u.adminTarget(userID)
+	if err != nil {
+		return err
+	}
+	if user.IsVerified {
+		return ErrAlreadyVerified
+	}
+	if user.Email == "" {
+		return ErrOtpUndeliverable
+	}
+
+	err = u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.InvalidateOtps(user.Email, domain.OtpPurposeVerify); err != nil {
+			return err
+		}
+		otp, _, err := tx.issueOtp(user.ID, user.Email, domain.OtpPurposeVerify, client.IP)
+		if err != nil {
+			return err
+		}
+
+		return tx.queueEmail(u.verificationEmail(user.Email, user.Locale, otp))
+	})
+	if err != nil {
+		return err
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminResendVerification, user.ID, client, nil)
+	return nil
+}
+
+func (u *Usecase) adminTarget(userID int) (*domain.User, error) {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func toAdminUser(user *domain.User) response.AdminUser {
+	return response.AdminUser{
+		ID:         user.ID,
+		Email:      user.Email,
+		Phone:      user.Phone,
+		Username:   user.Username,
+		Role:       user.Role,
+		IsVerified: user.IsVerified,
+		Disabled:   user.DisabledAt != nil,
+		DisabledAt: user.DisabledAt,
+		CreatedAt:  user.CreatedAt,
+	}
+}
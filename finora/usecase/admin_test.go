@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+func TestAdminEnableUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		prepare      func(*testEnv, *testing.T, *domain.User)
+		wantErr      error
+		wantDisabled bool
+	}{
+		{
+			name:    "disabled account",
+			prepare: func(e *testEnv, _ *testing.T, user *domain.User) { e.db.Model(user).Update("disabled_at", time.Now()) },
+		},
+		{
+			name: "deleted account",
+			prepare: func(e *testEnv, t *testing.T, user *domain.User) {
+				if err := e.repo.AnonymizeUser(user, accountThrottleKey(user.ID)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr:      ErrAccountDeleted,
+			wantDisabled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			admin := env.createUser(t, "admin@example.com")
+			user := env.createUser(t, "user@example.com")
+			tt.prepare(env, t, user)
+
+			if err := env.AdminEnableUser(admin.ID, user.ID, client); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			stored, err := env.repo.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if disabled := stored.DisabledAt != nil; disabled != tt.wantDisabled {
+				t.Fatalf("disabled = %v, want %v", disabled, tt.wantDisabled)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"log"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+)
+
+// audit appends an event to the audit trail. A failure is logged rather than
+// returned, since the action being recorded has already happened.
+func (u *Usecase) audit(event domain.AuditEvent, client inbound.Client) {
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+	if err := u.repo.CreateAuditEvent(&event); err != nil {
+		log.Printf("failed to record audit event %s: %v", event.Action, err)
+	}
+}
+
+// auditUserAction records an action taken by actorID against a user
+func (u *Usecase) auditUserAction(actorID int, action string, userID int, client inbound.Client, payload map[string]any) {
+	u.audit(domain.AuditEvent{
+		ActorID:    &actorID,
+		Action:     action,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   &userID,
+		Payload:    payload,
+	}, client)
+}
+
+// auditSelf records an action a user took on their own account
+func (u *Usecase) auditSelf(userID int, action string, client inbound.Client, payload map[string]any) {
+	u.auditUserAction(userID, action, userID, client, payload)
+}
+
+// AuditEvents searches the audit log for admins
+func (u *Usecase) AuditEvents(query inbound.AuditQuery, limit, offset int) (*response.AuditPage, error) {
+	events, total, err := u.repo.ListAuditEvents(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.AuditPage{Events: events, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+// SecurityEvents lists the audit events concerning a user's own account.
+// Which admin acted is not revealed.
+func (u *Usecase) SecurityEvents(userID, limit, offset int) (*response.SecurityEventPage, error) {
+	events, total, err := u.repo.ListUserAuditEvents(userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &response.SecurityEventPage{
+		Events: make([]response.SecurityEvent, 0, len(events)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for _, event := range events {
+		page.Events = append(page.Events, response.SecurityEvent{
+			Action:    event.Action,
+			IP:        event.IP,
+			UserAgent: event.UserAgent,
+			ByAdmin:   event.ActorID != nil && *event.ActorID != userID,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return page, nil
+}
+
+// RunAuditRetention deletes events older than the retention period every
+// PurgeInterval. It blocks, so run it in its own goroutine.
+func (u *Usecase) RunAuditRetention(cfg configs.Audit) {
+	if cfg.Retention <= 0 || cfg.PurgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+	for {
+		purged, err := u.repo.PurgeAuditEvents(time.Now().Add(-cfg.Retention))
+		if err != nil {
+			log.Printf("failed to purge audit events: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d audit events older than %s", purged, cfg.Retention)
+		}
+		<-ticker.C
+	}
+}
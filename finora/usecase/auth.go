@@ -1,73 +1,175 @@
 package usecase
 
 import (
+	"errors"
+
 	"github.com/ayyoob-k-a/finora/configs"
 	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/hasher"
+	"github.com/ayyoob-k-a/finora/mailer"
 	"github.com/ayyoob-k-a/finora/model/inbound"
 	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/oidc"
+	"github.com/ayyoob-k-a/finora/policy"
 	"github.com/ayyoob-k-a/finora/repo"
+	"github.com/ayyoob-k-a/finora/sms"
 	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
 )
 
+var ErrVerificationPending = errors.New("account verification pending, verify the otp sent to your email")
+
 type Usecase struct {
-	repo *repo.Repo
-	mail configs.Mail
+	repo      *repo.Repo
+	mail      configs.Mail
+	mailer    mailer.Mailer
+	templates *mailer.Templates
+	sms       sms.Sender
+	smsConfig configs.SMS
+	providers oidc.Providers
+	passwords *policy.Password
+	account   configs.Account
+
+	// outboxWake nudges the email dispatcher when new email is queued
+	outboxWake chan struct{}
 
 	// Add fields as needed for your repository
 }
 
-func NewUsecase(repo *repo.Repo, Mail configs.Mail) *Usecase {
+func NewUsecase(repo *repo.Repo, Mail configs.Mail, mailer mailer.Mailer, templates *mailer.Templates, sender sms.Sender, smsConfig configs.SMS, providers oidc.Providers, passwords *policy.Password, account configs.Account) *Usecase {
 	return &Usecase{
-		repo: repo,
-		mail: Mail,
+		repo:      repo,
+		mail:      Mail,
+		mailer:    mailer,
+		templates: templates,
+		sms:       sender,
+		smsConfig: smsConfig,
+		providers: providers,
+		passwords: passwords,
+		account:   account,
+
+		outboxWake: make(chan struct{}, 1),
 	}
 }
 
-func (u *Usecase) Signup(data domain.User) error {
+func (u *Usecase) Signup(req inbound.Signup, client inbound.Client) error {
 	var err error
 
-	data.ID, err = u.repo.Signup(data)
-	if err != nil {
-		return err
+	data := domain.User{
+		Email:    utils.NormalizeIdentifier(req.Email),
+		Password: req.Password,
+		Phone:    utils.NormalizeIdentifier(req.Phone),
+		Username: req.Username,
 	}
 
-	data.Otp, err = utils.GenerateOTP(6)
+	if req.Locale != "" {
+		if !u.templates.SupportsLocale(req.Locale) {
+			return ErrUnsupportedLocale
+		}
+		data.Locale = mailer.NormalizeLocale(req.Locale)
+	}
+
+	err = u.passwords.Check(data.Password, policy.Account{Email: data.Email, Username: data.Username})
 	if err != nil {
 		return err
 	}
 
-	err = u.repo.InsertOtp(data)
+	// The account, its code and the email carrying it are committed together,
+	// so a signup never ends up without a way to verify.
+	err = u.inTx(func(tx *Usecase) error {
+		data.ID, err = tx.repo.Signup(data)
+		if err != nil {
+			return err
+		}
+
+		otp, _, err := tx.issueOtp(data.ID, data.Email, domain.OtpPurposeVerify, client.IP)
+		if err != nil {
+			return err
+		}
+
+		return tx.queueEmail(u.verificationEmail(data.Email, data.Locale, otp))
+	})
 	if err != nil {
 		return err
 	}
 
-	go utils.SendVerificationEmail(data.Email, data.Otp, u.mail.SecretKey)
+	u.auditSelf(data.ID, domain.AuditSignup, client, nil)
 	return nil
 
 }
 
-func (u *Usecase) Login(data inbound.Login) (*response.AuthResponse, error) {
-	user, err := u.repo.Login(data)
+// Login checks the password. Users with two-factor authentication get an
+// MFAChallenge instead of tokens. Failed attempts are throttled per account
+// and per client address.
+func (u *Usecase) Login(data inbound.Login, client inbound.Client) (*response.AuthResponse, *response.MFAChallenge, error) {
+	if err := u.checkLoginThrottle(ipThrottleKey(client.IP), "too many failed logins from this network"); err != nil {
+		return nil, nil, err
+	}
+
+	data.Identifier = utils.NormalizeIdentifier(data.Identifier)
+	user, err := u.repo.GetUserByIdentifier(data.Identifier)
 	if err != nil {
-		return nil, err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := u.repo.VerifyDummyPassword(data.Password); err != nil {
+				return nil, nil, err
+			}
+			u.audit(domain.AuditEvent{
+				Action:  domain.AuditLoginFailed,
+				Payload: map[string]any{"identifier": utils.MaskIdentifier(data.Identifier)},
+			}, client)
+			u.recordLoginFailure(nil, client)
+			return nil, nil, ErrInvalidCredentials
+		}
+		return nil, nil, err
+	}
+
+	if err := u.checkLoginThrottle(accountThrottleKey(user.ID), "account temporarily locked after too many failed logins"); err != nil {
+		return nil, nil, err
 	}
 
-	accessToken, refreshToken, err := utils.GenerateToken(int(user.ID))
+	if _, err := u.repo.Login(data); err != nil {
+		if errors.Is(err, hasher.ErrBusy) {
+			return nil, nil, err
+		}
+		u.audit(domain.AuditEvent{
+			Action:     domain.AuditLoginFailed,
+			TargetType: domain.AuditTargetUser,
+			TargetID:   &user.ID,
+		}, client)
+		u.recordLoginFailure(user, client)
+		return nil, nil, err
+	}
+
+	// Only the account streak is cleared. Clearing the address too would let
+	// one valid account reset the limit for guesses against others.
+	if err := u.repo.ResetLoginThrottle(accountThrottleKey(user.ID)); err != nil {
+		return nil, nil, err
+	}
+
+	if !user.IsVerified {
+		return nil, nil, ErrVerificationPending
+	}
+	if user.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	mfa, err := u.twoFactorEnabled(user.ID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if mfa {
+		challenge, err := u.mfaChallenge(user.ID)
+		return nil, challenge, err
 	}
 
-	return &response.AuthResponse{
-		UserID:       int(user.ID),
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	res, err := u.startSession(user, client, loginMethodPassword)
+	return res, nil, err
 }
 
 func (u *Usecase) VerifyUser(email string) error {
-	return u.repo.VerifyUser(email)
+	return u.repo.VerifyUser(utils.NormalizeIdentifier(email))
 }
 
 func (u *Usecase) GetUserByEmail(email string) (*response.User, error) {
-	return u.repo.GetUserByEmail(email)
+	return u.repo.GetUserByEmail(utils.NormalizeIdentifier(email))
 }
@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/export"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrExportInProgress = errors.New("a data export is already being prepared")
+	ErrExportNotFound   = errors.New("data export not found")
+	ErrExportNotReady   = errors.New("data export is not ready to download")
+	ErrExportExpired    = errors.New("data export has expired, please request a new one")
+)
+
+// exportBatchSize is how many audit events are read at a time while building
+// an export
+const exportBatchSize = 1000
+
+// RequestExport starts building an archive of the user's data in the
+// background. Only one export may be in progress at a time; the database
+// refuses a second pending export even when two requests race.
+func (u *Usecase) RequestExport(userID int, client inbound.Client) (*domain.DataExport, error) {
+	exports, err := u.repo.ListDataExports(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range exports {
+		if existing.Status == domain.ExportPending {
+			return nil, ErrExportInProgress
+		}
+	}
+
+	record := &domain.DataExport{UserID: userID, Status: domain.ExportPending}
+	if err := u.repo.CreateDataExport(record); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrExportInProgress
+		}
+		return nil, err
+	}
+
+	u.auditSelf(userID, domain.AuditDataExport, client, map[string]any{"export_id": record.ID})
+
+	go u.buildExport(*record)
+	return record, nil
+}
+
+func (u *Usecase) ListExports(userID int) ([]domain.DataExport, error) {
+	return u.repo.ListDataExports(userID)
+}
+
+func (u *Usecase) GetExport(userID, id int) (*domain.DataExport, error) {
+	record, err := u.repo.GetDataExport(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportNotFound
+	}
+	return record, err
+}
+
+// ExportFile returns a finished export whose archive can be downloaded. An
+// export past its expiry is refused even before the cleanup job removes it.
+func (u *Usecase) ExportFile(userID, id int) (*domain.DataExport, error) {
+	record, err := u.GetExport(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status != domain.ExportReady {
+		return nil, ErrExportNotReady
+	}
+	if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+		return nil, ErrExportExpired
+	}
+	return record, nil
+}
+
+// ResumeDataExports restarts exports that were interrupted by a shutdown
+func (u *Usecase) ResumeDataExports() error {
+	exports, err := u.repo.ListPendingDataExports()
+	if err != nil {
+		return err
+	}
+
+	for _, record := range exports {
+		go u.buildExport(record)
+	}
+	return nil
+}
+
+// buildExport writes the archive and records the outcome. Failures are kept
+// on the record so the user can see them and request a new export.
+func (u *Usecase) buildExport(record domain.DataExport) {
+	path, size, err := u.writeExport(record)
+
+	now := time.Now()
+	expires := now.Add(u.account.ExportTTL)
+	record.CompletedAt = &now
+	record.ExpiresAt = &expires
+	if err != nil {
+		log.Printf("failed to build data export %d: %v", record.ID, err)
+		record.Status = domain.ExportFailed
+		record.Error = "the export could not be created, please try again"
+	} else {
+		record.Status = domain.ExportReady
+		record.FilePath = path
+		record.Size = size
+	}
+
+	if err := u.repo.SaveDataExport(&record); err != nil {
+		log.Printf("failed to save data export %d: %v", record.ID, err)
+	}
+}
+
+func (u *Usecase) writeExport(record domain.DataExport) (string, int64, error) {
+	sections, err := u.exportSections(record.UserID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := os.MkdirAll(u.account.ExportDir, 0o700); err != nil {
+		return "", 0, err
+	}
+	file, err := os.CreateTemp(u.account.ExportDir, fmt.Sprintf("export-%d-*.zip.tmp", record.ID))
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(file.Name())
+
+	if err := export.WriteZip(file, sections); err != nil {
+		file.Close()
+		return "", 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return "", 0, err
+	}
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+
+	path := filepath.Join(u.account.ExportDir, fmt.Sprintf("export-%d-%d.zip", record.UserID, record.ID))
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+// exportSections gathers everything stored about the user. Secrets such as
+// password hashes, token hashes and two-factor seeds are left out.
+func (u *Usecase) exportSections(userID int) ([]export.Section, error) {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.repo.ListAllSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := u.repo.ListExternalIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, err := u.repo.ListPATs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor := []domain.TwoFactor{}
+	tf, err := u.repo.GetTwoFactor(userID)
+	if err == nil {
+		twoFactor = append(twoFactor, *tf)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	var events []domain.AuditEvent
+	for offset := 0; ; offset += exportBatchSize {
+		batch, _, err := u.repo.ListUserAuditEvents(userID, exportBatchSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, batch...)
+		if len(batch) < exportBatchSize {
+			break
+		}
+	}
+
+	var notifications []domain.Notification
+	for beforeID := 0; ; {
+		batch, err := u.repo.ListNotifications(userID, beforeID, exportBatchSize, "", false)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, batch...)
+		if len(batch) < exportBatchSize {
+			break
+		}
+		beforeID = batch[len(batch)-1].ID
+	}
+
+	return []export.Section{
+		{Name: "profile", Records: toProfile(user)},
+		{Name: "sessions", Records: sessions},
+		{Name: "linked_providers", Records: identities},
+		{Name: "access_tokens", Records: tokens},
+		{Name: "two_factor", Records: twoFactor},
+		{Name: "security_events", Records: events},
+		{Name: "notifications", Records: notifications},
+	}, nil
+}
+
+// purgeExpiredExports deletes archives that are past their download window
+func (u *Usecase) purgeExpiredExports() {
+	exports, err := u.repo.ListExpiredDataExports(time.Now())
+	if err != nil {
+		log.Printf("failed to list expired data exports: %v", err)
+		return
+	}
+
+	for _, record := range exports {
+		u.removeExport(record)
+	}
+}
+
+func (u *Usecase) removeExport(record domain.DataExport) {
+	if record.FilePath != "" {
+		if err := os.Remove(record.FilePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("failed to remove data export %d: %v", record.ID, err)
+			return
+		}
+	}
+	if err := u.repo.DeleteDataExport(record.ID); err != nil {
+		log.Printf("failed to delete data export %d: %v", record.ID, err)
+	}
+}
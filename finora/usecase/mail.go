@@ -0,0 +1,277 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/repo"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmailNotDead = errors.New("no dead-lettered email with that id")
+	ErrEmailExpired = errors.New("this email carried a one-time code that has expired and cannot be resent")
+)
+
+// outboxLease is how long a claimed email is hidden from other dispatchers.
+// An email whose sender died mid-send is picked up again after it. Emails
+// are claimed one at a time, so the lease only has to outlast a single send,
+// which the SMTP timeouts keep well under it.
+const outboxLease = 2 * time.Minute
+
+// otpTemplates are the emails carrying a one-time code. They are useless once
+// the code has expired, so they are not sent or retried after otpTTL.
+var otpTemplates = []string{mailer.TemplateVerify, mailer.TemplateReset}
+
+// outboxPurgeInterval is how often sent emails past their retention are deleted
+const outboxPurgeInterval = time.Hour
+
+func (u *Usecase) verificationEmail(to, locale, code string) (mailer.Message, error) {
+	return u.templates.Render(mailer.TemplateVerify, locale, to, mailer.VerifyData{
+		Otp:              code,
+		ExpiresInMinutes: int(otpTTL / time.Minute),
+	})
+}
+
+// queueEmail adds msg to the outbox. It takes the result of a mailer builder
+// directly. Inside inTx the email is committed or rolled back together with
+// the rest of the transaction.
+func (u *Usecase) queueEmail(msg mailer.Message, err error) error {
+	if err != nil {
+		return err
+	}
+
+	email := &domain.OutboxEmail{
+		Recipient:     msg.To,
+		Subject:       msg.Subject,
+		HTML:          msg.HTML,
+		Text:          msg.Text,
+		Template:      msg.Template,
+		Locale:        msg.Locale,
+		Version:       msg.Version,
+		Status:        domain.EmailPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := u.repo.CreateOutboxEmail(email); err != nil {
+		return err
+	}
+
+	u.wakeDispatcher()
+	return nil
+}
+
+// inTx runs fn with a Usecase whose repository is bound to one transaction.
+// The dispatcher is woken after the commit, since emails queued inside are
+// not visible to it before then.
+func (u *Usecase) inTx(fn func(tx *Usecase) error) error {
+	err := u.repo.Transaction(func(txRepo *repo.Repo) error {
+		tx := *u
+		tx.repo = txRepo
+		return fn(&tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.wakeDispatcher()
+	return nil
+}
+
+func (u *Usecase) wakeDispatcher() {
+	select {
+	case u.outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// RunEmailDispatcher sends queued email until the process exits. It polls
+// every PollInterval and is woken early when new email is queued. It blocks,
+// so run it in its own goroutine.
+func (u *Usecase) RunEmailDispatcher(cfg configs.Outbox) {
+	if cfg.PollInterval <= 0 || cfg.BatchSize <= 0 {
+		log.Printf("email dispatcher disabled: EMAIL_POLL_INTERVAL and EMAIL_BATCH_SIZE must be positive")
+		return
+	}
+
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		// A full batch means more may be waiting
+		for claimed := cfg.BatchSize; claimed == cfg.BatchSize; {
+			claimed = u.dispatchEmails(cfg)
+		}
+
+		// A dead-lettered code stays retryable while it is valid, and its
+		// body goes as soon as it expires.
+		if _, err := u.repo.DropOutboxEmailBodies(domain.EmailDead, otpTemplates, time.Now().Add(-otpTTL)); err != nil {
+			log.Printf("failed to drop expired codes from dead-lettered emails: %v", err)
+		}
+
+		if cfg.SentRetention > 0 && time.Since(lastPurge) > outboxPurgeInterval {
+			lastPurge = time.Now()
+			if _, err := u.repo.PurgeSentOutboxEmails(lastPurge.Add(-cfg.SentRetention)); err != nil {
+				log.Printf("failed to purge sent emails: %v", err)
+			}
+			if _, err := u.repo.PurgeDeadOutboxEmails(lastPurge.Add(-cfg.SentRetention)); err != nil {
+				log.Printf("failed to purge dead-lettered emails: %v", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-u.outboxWake:
+		}
+	}
+}
+
+// dispatchEmails sends up to one batch of due emails and returns how many it
+// claimed
+func (u *Usecase) dispatchEmails(cfg configs.Outbox) int {
+	claimed := 0
+	for ; claimed < cfg.BatchSize; claimed++ {
+		email, err := u.repo.ClaimOutboxEmail(time.Now(), outboxLease)
+		if err != nil {
+			log.Printf("failed to claim queued email: %v", err)
+			break
+		}
+		if email == nil {
+			break
+		}
+		u.dispatchEmail(cfg, email)
+	}
+
+	return claimed
+}
+
+// dispatchEmail sends one claimed email and records the outcome, unless
+// another dispatcher took the email over in the meantime.
+func (u *Usecase) dispatchEmail(cfg configs.Outbox, email *domain.OutboxEmail) {
+	var held bool
+	var err error
+	switch {
+	case otpEmailExpired(email):
+		log.Printf("email %d dead-lettered: its one-time code expired before it could be sent", email.ID)
+		held, err = u.repo.MarkOutboxEmailDead(email.ID, email.LeaseToken, ErrEmailExpired.Error(), true)
+	case time.Now().After(email.NextAttemptAt):
+		// The lease ran out before sending started; another dispatcher may
+		// already have the email.
+		return
+	default:
+		sendErr := u.mailer.Send(mailer.Message{
+			To:      email.Recipient,
+			Subject: email.Subject,
+			HTML:    email.HTML,
+			Text:    email.Text,
+		})
+		switch {
+		case sendErr == nil:
+			held, err = u.repo.MarkOutboxEmailSent(email.ID, email.LeaseToken)
+		case email.Attempts < cfg.MaxAttempts && !errors.Is(sendErr, mailer.ErrNoRecipient):
+			next := time.Now().Add(emailBackoff(cfg, email.Attempts))
+			held, err = u.repo.MarkOutboxEmailFailed(email.ID, email.LeaseToken, sendErr.Error(), next)
+		default:
+			log.Printf("email %d dead-lettered after %d attempts: %v", email.ID, email.Attempts, sendErr)
+			held, err = u.repo.MarkOutboxEmailDead(email.ID, email.LeaseToken, sendErr.Error(), otpEmailExpired(email))
+		}
+	}
+
+	if err != nil {
+		log.Printf("failed to update queued email %d: %v", email.ID, err)
+	} else if !held {
+		log.Printf("lease on queued email %d expired before its outcome was recorded", email.ID)
+	}
+}
+
+func isOtpEmail(email *domain.OutboxEmail) bool {
+	return slices.Contains(otpTemplates, email.Template)
+}
+
+// otpEmailExpired reports whether email carried a one-time code that has
+// expired since it was queued
+func otpEmailExpired(email *domain.OutboxEmail) bool {
+	return isOtpEmail(email) && time.Since(email.CreatedAt) > otpTTL
+}
+
+// emailBackoff is the wait before the next attempt after attempts failures
+func emailBackoff(cfg configs.Outbox, attempts int) time.Duration {
+	backoff := cfg.BaseBackoff
+	for i := 1; i < attempts && backoff < cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, cfg.MaxBackoff)
+}
+
+// OutboxEmails lists queued, sent or dead-lettered email for admins. Bodies
+// are never included.
+func (u *Usecase) OutboxEmails(status string, limit, offset int) (*response.EmailPage, error) {
+	emails, total, err := u.repo.ListOutboxEmails(status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.EmailPage{Emails: emails, Total: total, Limit: limit, Offset: offset}, nil
+}
+
+func (u *Usecase) EmailTemplates() []mailer.TemplateInfo {
+	return u.templates.List()
+}
+
+// PreviewEmailTemplate renders a template with sample data. An empty locale
+// previews the default.
+func (u *Usecase) PreviewEmailTemplate(name, locale string) (*response.EmailPreview, error) {
+	msg, err := u.templates.Preview(name, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.EmailPreview{
+		Template: msg.Template,
+		Locale:   msg.Locale,
+		Version:  msg.Version,
+		Subject:  msg.Subject,
+		HTML:     msg.HTML,
+		Text:     msg.Text,
+	}, nil
+}
+
+// RetryEmail requeues a dead-lettered email with a fresh attempt count.
+// Emails whose one-time code has expired, or whose body is already gone, are
+// refused.
+func (u *Usecase) RetryEmail(actorID, emailID int, client inbound.Client) error {
+	email, err := u.repo.GetOutboxEmail(emailID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrEmailNotDead
+		}
+		return err
+	}
+	if email.Status == domain.EmailDead && (otpEmailExpired(email) || email.HTML == "" && email.Text == "") {
+		return ErrEmailExpired
+	}
+
+	retried, err := u.repo.RetryOutboxEmail(emailID)
+	if err != nil {
+		return err
+	}
+	if !retried {
+		return ErrEmailNotDead
+	}
+
+	u.audit(domain.AuditEvent{
+		ActorID:    &actorID,
+		Action:     domain.AuditAdminRetryEmail,
+		TargetType: domain.AuditTargetEmail,
+		TargetID:   &emailID,
+	}, client)
+	u.wakeDispatcher()
+	return nil
+}
@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/mailer"
+)
+
+var testOutbox = configs.Outbox{
+	PollInterval: time.Second,
+	BatchSize:    10,
+	MaxAttempts:  3,
+	BaseBackoff:  time.Minute,
+	MaxBackoff:   time.Hour,
+}
+
+func (e *testEnv) queueCodeEmail(t *testing.T, to string) *domain.OutboxEmail {
+	t.Helper()
+	if err := e.queueEmail(e.verificationEmail(to, "en", "123456")); err != nil {
+		t.Fatal(err)
+	}
+	return e.lastOutboxEmail(t)
+}
+
+func (e *testEnv) queueAlertEmail(t *testing.T, to string) *domain.OutboxEmail {
+	t.Helper()
+	msg, err := e.templates.Preview(mailer.TemplateLoginAlert, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.To = to
+	if err := e.queueEmail(msg, nil); err != nil {
+		t.Fatal(err)
+	}
+	return e.lastOutboxEmail(t)
+}
+
+func (e *testEnv) lastOutboxEmail(t *testing.T) *domain.OutboxEmail {
+	t.Helper()
+	var email domain.OutboxEmail
+	if err := e.db.Order("id DESC").First(&email).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &email
+}
+
+func (e *testEnv) outboxEmail(t *testing.T, id int) *domain.OutboxEmail {
+	t.Helper()
+	email, err := e.repo.GetOutboxEmail(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return email
+}
+
+func TestOutboxLease(t *testing.T) {
+	env := newTestEnv(t)
+	queued := env.queueAlertEmail(t, "lease@example.com")
+
+	now := time.Now()
+	first, err := env.repo.ClaimOutboxEmail(now, outboxLease)
+	if err != nil || first == nil || first.ID != queued.ID {
+		t.Fatalf("first claim = %v, %v", first, err)
+	}
+	if first.Attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", first.Attempts)
+	}
+
+	if again, err := env.repo.ClaimOutboxEmail(now, outboxLease); err != nil || again != nil {
+		t.Fatalf("leased email claimed again: %v, %v", again, err)
+	}
+
+	// Another dispatcher takes over once the lease has run out
+	second, err := env.repo.ClaimOutboxEmail(now.Add(outboxLease+time.Second), outboxLease)
+	if err != nil || second == nil || second.ID != queued.ID {
+		t.Fatalf("claim after lease expiry = %v, %v", second, err)
+	}
+	if second.LeaseToken == first.LeaseToken {
+		t.Fatal("a new claim must get a new lease token")
+	}
+
+	if held, err := env.repo.MarkOutboxEmailSent(first.ID, first.LeaseToken); err != nil || held {
+		t.Fatalf("stale lease recorded an outcome: held=%v err=%v", held, err)
+	}
+	if held, err := env.repo.MarkOutboxEmailSent(second.ID, second.LeaseToken); err != nil || !held {
+		t.Fatalf("current lease could not record an outcome: held=%v err=%v", held, err)
+	}
+	if got := env.outboxEmail(t, queued.ID); got.Status != domain.EmailSent || got.HTML != "" || got.Text != "" {
+		t.Fatalf("sent email = %+v, want sent with the body dropped", got)
+	}
+}
+
+func TestOutboxDispatch(t *testing.T) {
+	sendErr := errors.New("smtp is down")
+
+	tests := []struct {
+		name         string
+		queue        func(*testEnv, *testing.T) *domain.OutboxEmail
+		prepare      func(*testEnv, *domain.OutboxEmail)
+		sendErr      error
+		wantStatus   string
+		wantAttempts int
+		wantBody     bool
+		wantSent     bool
+	}{
+		{
+			name:         "delivered",
+			queue:        func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueAlertEmail(t, "a@example.com") },
+			wantStatus:   domain.EmailSent,
+			wantAttempts: 1,
+			wantSent:     true,
+		},
+		{
+			name:         "failure is retried later",
+			queue:        func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueAlertEmail(t, "a@example.com") },
+			sendErr:      sendErr,
+			wantStatus:   domain.EmailPending,
+			wantAttempts: 1,
+			wantBody:     true,
+		},
+		{
+			name:  "dead-lettered after the last attempt",
+			queue: func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueAlertEmail(t, "a@example.com") },
+			prepare: func(e *testEnv, email *domain.OutboxEmail) {
+				e.db.Model(email).Update("attempts", testOutbox.MaxAttempts-1)
+			},
+			sendErr:      sendErr,
+			wantStatus:   domain.EmailDead,
+			wantAttempts: testOutbox.MaxAttempts,
+			wantBody:     true,
+		},
+		{
+			name:  "dead-lettered code keeps its body while valid",
+			queue: func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueCodeEmail(t, "a@example.com") },
+			prepare: func(e *testEnv, email *domain.OutboxEmail) {
+				e.db.Model(email).Update("attempts", testOutbox.MaxAttempts-1)
+			},
+			sendErr:      sendErr,
+			wantStatus:   domain.EmailDead,
+			wantAttempts: testOutbox.MaxAttempts,
+			wantBody:     true,
+		},
+		{
+			name:  "expired code is not sent",
+			queue: func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueCodeEmail(t, "a@example.com") },
+			prepare: func(e *testEnv, email *domain.OutboxEmail) {
+				e.db.Model(email).Update("created_at", time.Now().Add(-otpTTL-time.Minute))
+			},
+			wantStatus:   domain.EmailDead,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			queued := tt.queue(env, t)
+			if tt.prepare != nil {
+				tt.prepare(env, queued)
+			}
+			env.mail.SetError(tt.sendErr)
+
+			if claimed := env.dispatchEmails(testOutbox); claimed != 1 {
+				t.Fatalf("claimed %d emails, want 1", claimed)
+			}
+
+			got := env.outboxEmail(t, queued.ID)
+			if got.Status != tt.wantStatus || got.Attempts != tt.wantAttempts {
+				t.Fatalf("status=%s attempts=%d, want %s and %d", got.Status, got.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if hasBody := got.HTML != "" || got.Text != ""; hasBody != tt.wantBody {
+				t.Fatalf("body kept = %v, want %v", hasBody, tt.wantBody)
+			}
+			if _, sent := env.mail.Last(queued.Recipient); sent != tt.wantSent {
+				t.Fatalf("delivered = %v, want %v", sent, tt.wantSent)
+			}
+			if got.Status == domain.EmailPending && !got.NextAttemptAt.After(time.Now()) {
+				t.Fatal("a failed email must wait before its next attempt")
+			}
+		})
+	}
+}
+
+func TestOutboxDeadLetterRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		queue   func(*testEnv, *testing.T) *domain.OutboxEmail
+		expire  bool
+		wantErr error
+	}{
+		{
+			name:  "email is delivered after a retry",
+			queue: func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueAlertEmail(t, "retry@example.com") },
+		},
+		{
+			name:  "valid code is delivered with its body after a retry",
+			queue: func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueCodeEmail(t, "retry@example.com") },
+		},
+		{
+			name:    "expired code cannot be retried",
+			queue:   func(e *testEnv, t *testing.T) *domain.OutboxEmail { return e.queueCodeEmail(t, "retry@example.com") },
+			expire:  true,
+			wantErr: ErrEmailExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			queued := tt.queue(env, t)
+
+			env.db.Model(queued).Update("attempts", testOutbox.MaxAttempts-1)
+			env.mail.SetError(errors.New("smtp is down"))
+			env.dispatchEmails(testOutbox)
+			if got := env.outboxEmail(t, queued.ID); got.Status != domain.EmailDead {
+				t.Fatalf("status = %s, want dead", got.Status)
+			}
+
+			if tt.expire {
+				env.db.Model(queued).Update("created_at", time.Now().Add(-otpTTL-time.Minute))
+				if _, err := env.repo.DropOutboxEmailBodies(domain.EmailDead, otpTemplates, time.Now().Add(-otpTTL)); err != nil {
+					t.Fatal(err)
+				}
+				if got := env.outboxEmail(t, queued.ID); got.HTML != "" || got.Text != "" {
+					t.Fatal("expired code still stored in a dead-lettered email")
+				}
+			}
+
+			err := env.RetryEmail(1, queued.ID, client)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("retry error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if err := env.RetryEmail(1, queued.ID, client); !errors.Is(err, ErrEmailNotDead) {
+				t.Fatalf("second retry error = %v, want ErrEmailNotDead", err)
+			}
+
+			env.mail.SetError(nil)
+			env.dispatchEmails(testOutbox)
+
+			got := env.outboxEmail(t, queued.ID)
+			if got.Status != domain.EmailSent || got.Attempts != 1 {
+				t.Fatalf("status=%s attempts=%d, want sent on the first new attempt", got.Status, got.Attempts)
+			}
+			msg, sent := env.mail.Last(queued.Recipient)
+			if !sent || msg.HTML == "" || msg.Text == "" {
+				t.Fatalf("retried email delivered=%v with body %q", sent, msg.Text)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/hasher"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/policy"
+	"github.com/ayyoob-k-a/finora/repo"
+	"github.com/ayyoob-k-a/finora/sms"
+	"github.com/ayyoob-k-a/finora/utils"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The usecase tests run against an in-memory SQLite database. The schema is
+// the one AutoMigrate creates for Postgres; the Postgres-only parts (row
+// locks, advisory locks, the audit trigger) are not exercised here.
+
+const testPassword = "correct horse battery staple"
+
+func TestMain(m *testing.M) {
+	if err := utils.InitOTPKey(configs.OTP{HMACKey: strings.Repeat("k", 64)}); err != nil {
+		log.Fatal(err)
+	}
+
+	keyDir, err := os.MkdirTemp("", "finora-keys")
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = utils.InitSigningKeys(configs.JWT{
+		Issuer:      "finora-test",
+		Algorithm:   "EdDSA",
+		KeyDir:      keyDir,
+		KeyID:       "test",
+		GenerateKey: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code := m.Run()
+	os.RemoveAll(keyDir)
+	os.Exit(code)
+}
+
+// testEnv is a Usecase wired to a fresh database and in-memory mail and SMS
+type testEnv struct {
+	*Usecase
+	db   *gorm.DB
+	mail *mailer.Memory
+	sms  *sms.Memory
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_busy_timeout=5000", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent), TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keep the shared in-memory database alive and serialise access to it
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(&domain.User{}, &domain.Otp{}, &domain.RefreshToken{}, &domain.Session{}, &domain.TwoFactor{}, &domain.RecoveryCode{}, &domain.MFAChallenge{}, &domain.LoginThrottle{}, &domain.ExternalIdentity{}, &domain.OAuthState{}, &domain.PersonalAccessToken{}, &domain.AuditEvent{}, &domain.DataExport{}, &domain.OutboxEmail{}, &domain.SMSMessage{}, &domain.Notification{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passwordHasher, err := hasher.New(configs.Password{
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		BcryptCost:        bcrypt.MinCost,
+		Workers:           2,
+		QueueTimeout:      time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	passwords, err := policy.NewPassword(configs.PasswordPolicy{MinLength: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := mailer.LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := &testEnv{db: db, mail: mailer.NewMemory(), sms: sms.NewMemory()}
+	env.Usecase = NewUsecase(repo.NewRepo(db, passwordHasher), configs.Mail{URL: "https://finora.test"}, env.mail, templates,
+		env.sms, configs.SMS{MaxPerNumberHour: 100, MaxPerNumberDay: 100}, nil, passwords, configs.Account{ExportDir: t.TempDir()})
+	return env
+}
+
+// createUser stores a verified account with testPassword
+func (e *testEnv) createUser(t *testing.T, email string) *domain.User {
+	t.Helper()
+
+	id, err := e.repo.Signup(domain.User{Email: email, Password: testPassword, Username: strings.Split(email, "@")[0], IsVerified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := e.repo.GetUserByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// issueCode stores a fresh OTP for identifier and returns the plain code
+func (e *testEnv) issueCode(t *testing.T, userID int, identifier, purpose string) string {
+	t.Helper()
+
+	code, _, err := e.issueOtp(userID, identifier, purpose, "192.0.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// wrongCode returns a well-formed code that differs from code
+func wrongCode(code string) string {
+	if code == "000000" {
+		return "111111"
+	}
+	return "000000"
+}
+
+// client is the address most tests make their requests from
+var client = inbound.Client{IP: "192.0.2.1", UserAgent: "test", Device: "test"}
+
+// newSession stores a session for user that was authenticated at authenticatedAt
+func (e *testEnv) newSession(t *testing.T, user *domain.User, authenticatedAt time.Time) *domain.Session {
+	t.Helper()
+
+	session := &domain.Session{UserID: user.ID, Device: client.Device, IP: client.IP, LastSeenAt: authenticatedAt, AuthenticatedAt: &authenticatedAt}
+	if err := e.repo.CreateSession(session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"errors"
+	"log"
+	"slices"
+	"strconv"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+)
+
+var (
+	ErrUnknownNotificationType = errors.New("unknown notification type")
+	ErrNotificationNotFound    = errors.New("notification not found")
+	ErrInvalidCursor           = errors.New("invalid cursor")
+)
+
+// Notify publishes a notification to a user. It is how other modules reach
+// the user in the app; payload is stored as JSON and may be nil.
+func (u *Usecase) Notify(userID int, kind, title, body string, payload map[string]any) error {
+	if !slices.Contains(domain.NotificationTypes, kind) {
+		return ErrUnknownNotificationType
+	}
+
+	return u.repo.CreateNotification(&domain.Notification{
+		UserID:  userID,
+		Type:    kind,
+		Title:   title,
+		Body:    body,
+		Payload: payload,
+	})
+}
+
+// notifySecurity publishes a security alert. Like audit, a failure is logged
+// rather than returned, since the action has already happened.
+func (u *Usecase) notifySecurity(userID int, title, body string, payload map[string]any) {
+	if err := u.Notify(userID, domain.NotificationSecurityAlert, title, body, payload); err != nil {
+		log.Printf("failed to notify user %d of %q: %v", userID, title, err)
+	}
+}
+
+// ListNotifications returns a page of the user's notifications, newest first.
+// Pages are keyed by id rather than offset, so notifications arriving while
+// the user scrolls do not shift later pages.
+func (u *Usecase) ListNotifications(userID int, query inbound.NotificationQuery, limit int) (*response.NotificationPage, error) {
+	if query.Type != "" && !slices.Contains(domain.NotificationTypes, query.Type) {
+		return nil, ErrUnknownNotificationType
+	}
+
+	var beforeID int
+	if query.Cursor != "" {
+		var err error
+		if beforeID, err = decodeCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether there is another page
+	notifications, err := u.repo.ListNotifications(userID, beforeID, limit+1, query.Type, query.Unread)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &response.NotificationPage{Notifications: notifications, Limit: limit}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		page.NextCursor = encodeCursor(page.Notifications[limit-1].ID)
+	}
+	if page.Notifications == nil {
+		page.Notifications = []domain.Notification{}
+	}
+	return page, nil
+}
+
+func (u *Usecase) UnreadNotificationCount(userID int) (*response.UnreadCount, error) {
+	count, err := u.repo.CountUnreadNotifications(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.UnreadCount{Unread: count}, nil
+}
+
+func (u *Usecase) MarkNotificationRead(userID, id int) error {
+	ok, err := u.repo.MarkNotificationRead(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllNotificationsRead reports how many notifications were unread
+func (u *Usecase) MarkAllNotificationsRead(userID int) (int64, error) {
+	return u.repo.MarkAllNotificationsRead(userID)
+}
+
+func (u *Usecase) DeleteNotification(userID, id int) error {
+	ok, err := u.repo.DeleteNotification(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// Cursors are opaque to clients so the paging key can change later
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
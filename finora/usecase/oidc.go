@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/oidc"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+// OAuthStateTTL is how long the user has to finish at the provider
+const OAuthStateTTL = 10 * time.Minute
+
+var (
+	ErrInvalidOAuthState       = errors.New("invalid or expired login request, please start again")
+	ErrOIDCEmailNotVerified    = errors.New("the provider did not return a verified email address")
+	ErrIdentityLinkedElsewhere = errors.New("this provider account is already linked to another user")
+	ErrIdentityNotLinked       = errors.New("provider is not linked to this account")
+	ErrLastSignInMethod        = errors.New("cannot remove the only way to sign in to this account")
+)
+
+// StartOIDC begins an authorization code flow with PKCE and returns the URL to
+// send the user to, along with the state. The caller must bind the state to
+// the browser, see OIDCCallback. linkUserID is non-zero when a signed-in user
+// is attaching the provider to their account.
+func (u *Usecase) StartOIDC(ctx context.Context, providerName string, linkUserID int) (authURL, state string, err error) {
+	provider, err := u.providers.Get(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	pending := &domain.OAuthState{
+		State:        oidc.NewState(),
+		Provider:     providerName,
+		Nonce:        oidc.NewState(),
+		CodeVerifier: oidc.NewCodeVerifier(),
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(OAuthStateTTL),
+	}
+	if err := u.repo.CreateOAuthState(pending); err != nil {
+		return "", "", err
+	}
+
+	authURL, err = provider.AuthCodeURL(ctx, pending.State, pending.Nonce, pending.CodeVerifier)
+	return authURL, pending.State, err
+}
+
+// OIDCCallback finishes the flow started by StartOIDC. It either links the
+// identity to the user who started it, or signs the user in. browserState is
+// the state the browser was given when the flow started; a callback arriving
+// in any other browser is refused, so a third party cannot get someone to
+// complete a login or link they started.
+func (u *Usecase) OIDCCallback(ctx context.Context, providerName, code, state, browserState string, client inbound.Client) (*response.OIDCResult, error) {
+	provider, err := u.providers.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrInvalidOAuthState
+	}
+
+	pending, err := u.repo.ConsumeOAuthState(state)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOAuthState
+		}
+		return nil, err
+	}
+	if pending.Provider != providerName || time.Now().After(pending.ExpiresAt) {
+		return nil, ErrInvalidOAuthState
+	}
+
+	tokens, err := provider.Exchange(ctx, code, pending.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, tokens.IDToken, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending.LinkUserID != 0 {
+		identity, err := u.linkIdentity(pending.LinkUserID, providerName, claims)
+		if err != nil {
+			return nil, err
+		}
+		u.auditSelf(pending.LinkUserID, domain.AuditIdentityLink, client, map[string]any{"provider": providerName})
+		return &response.OIDCResult{LinkedIdentity: identity}, nil
+	}
+
+	user, err := u.userForIdentity(providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa, err := u.twoFactorEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa {
+		challenge, err := u.mfaChallenge(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &response.OIDCResult{MFAChallenge: challenge}, nil
+	}
+
+	auth, err := u.startSession(user, client, loginMethodOIDC+":"+providerName)
+	if err != nil {
+		return nil, err
+	}
+	return &response.OIDCResult{Auth: auth}, nil
+}
+
+// userForIdentity finds the user an external identity signs in as. Unknown
+// identities are linked to the account with the same verified email, or a
+// new account is created for them.
+func (u *Usecase) userForIdentity(providerName string, claims *oidc.Claims) (*domain.User, error) {
+	identity, err := u.repo.GetExternalIdentity(providerName, claims.Subject)
+	if err == nil {
+		return u.repo.GetUserByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	email := utils.NormalizeIdentifier(claims.Email)
+	user, err := u.repo.GetUserByIdentifier(email)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		user = &domain.User{Email: email, IsVerified: true}
+		if err := u.repo.CreateUser(user); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.IsVerified:
+		// The provider has proven ownership of the address. Whoever signed up
+		// with it did not, so nothing they set up may survive the handover.
+		if err := u.repo.ClaimUnverifiedUser(user.ID); err != nil {
+			return nil, err
+		}
+		user.IsVerified, user.Password = true, ""
+	}
+
+	err = u.repo.CreateExternalIdentity(&domain.ExternalIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (u *Usecase) linkIdentity(userID int, providerName string, claims *oidc.Claims) (*response.Identity, error) {
+	identity, err := u.repo.GetExternalIdentity(providerName, claims.Subject)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if identity != nil && identity.UserID != userID {
+		return nil, ErrIdentityLinkedElsewhere
+	}
+
+	if identity == nil {
+		identity = &domain.ExternalIdentity{
+			UserID:   userID,
+			Provider: providerName,
+			Subject:  claims.Subject,
+			Email:    utils.NormalizeIdentifier(claims.Email),
+		}
+		if err := u.repo.CreateExternalIdentity(identity); err != nil {
+			return nil, err
+		}
+	}
+
+	return toIdentityResponse(identity), nil
+}
+
+func (u *Usecase) ListIdentities(userID int) ([]response.Identity, error) {
+	identities, err := u.repo.ListExternalIdentities(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]response.Identity, 0, len(identities))
+	for i := range identities {
+		res = append(res, *toIdentityResponse(&identities[i]))
+	}
+	return res, nil
+}
+
+// UnlinkIdentity detaches a provider, unless it is the only way left to sign
+// in. Accounts with an email or phone can always use an OTP.
+func (u *Usecase) UnlinkIdentity(userID int, providerName string, client inbound.Client) error {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	identities, err := u.repo.ListExternalIdentities(userID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" && user.Phone == "" && len(identities) <= 1 {
+		return ErrLastSignInMethod
+	}
+
+	deleted, err := u.repo.DeleteExternalIdentity(userID, providerName)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrIdentityNotLinked
+	}
+
+	u.auditSelf(userID, domain.AuditIdentityUnlink, client, map[string]any{"provider": providerName})
+	return nil
+}
+
+// OIDCProviders lists the configured provider names
+func (u *Usecase) OIDCProviders() []string {
+	return u.providers.Names()
+}
+
+func toIdentityResponse(identity *domain.ExternalIdentity) *response.Identity {
+	return &response.Identity{
+		Provider:  identity.Provider,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt,
+	}
+}
@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	otpLength      = 6
+	otpTTL         = 10 * time.Minute
+	otpMaxAttempts = 5
+
+	otpResendCooldown        = 60 * time.Second
+	otpSendWindow            = 24 * time.Hour
+	otpMaxSendsPerIdentifier = 5
+	otpMaxSendsPerIP         = 20
+)
+
+var (
+	ErrInvalidOtp          = errors.New("invalid otp")
+	ErrOtpExpired          = errors.New("otp has expired")
+	ErrOtpAttemptsExceeded = errors.New("too many attempts, request a new otp")
+	ErrAlreadyVerified     = errors.New("user is already verified")
+)
+
+// RateLimitError is returned when a request is refused because of a cooldown
+// or a send cap. RetryAfter tells the client how long to wait.
+type RateLimitError struct {
+	Reason     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s, retry after %d seconds", e.Reason, e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds rounds RetryAfter up to whole seconds
+func (e *RateLimitError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// issueOtp generates a fresh code for the identifier and stores its hash.
+// The plain code is returned along with the stored record so it can be
+// delivered to the user.
+func (u *Usecase) issueOtp(userID int, identifier, purpose, ip string) (string, *domain.Otp, error) {
+	code, err := utils.GenerateOTP(otpLength)
+	if err != nil {
+		return "", nil, err
+	}
+
+	otp := &domain.Otp{
+		UserID:     userID,
+		Identifier: identifier,
+		Purpose:    purpose,
+		CodeHash:   utils.HashOTP(identifier, purpose, code),
+		ExpiresAt:  time.Now().Add(otpTTL),
+		RequestIP:  ip,
+	}
+	if err := u.repo.CreateOtp(otp); err != nil {
+		return "", nil, err
+	}
+
+	return code, otp, nil
+}
+
+// recordOtpSend stores a spent OTP without a code for a request that had
+// nothing to send. It counts towards the send limits like a real code but can
+// never be verified.
+func (u *Usecase) recordOtpSend(identifier, purpose, ip string) error {
+	return u.repo.CreateOtp(&domain.Otp{
+		Identifier: identifier,
+		Purpose:    purpose,
+		ExpiresAt:  time.Now(),
+		Consumed:   true,
+		RequestIP:  ip,
+	})
+}
+
+// checkOtpSendLimits enforces the per-IP and per-identifier caps over a rolling
+// window, and the cooldown between two sends for the same purpose.
+func (u *Usecase) checkOtpSendLimits(identifier, purpose, ip string) error {
+	now := time.Now()
+	since := now.Add(-otpSendWindow)
+
+	count, oldest, err := u.repo.CountOtpsByIP(ip, since)
+	if err != nil {
+		return err
+	}
+	if count >= otpMaxSendsPerIP {
+		return &RateLimitError{Reason: "too many otp requests from this network", RetryAfter: oldest.Add(otpSendWindow).Sub(now)}
+	}
+
+	count, oldest, err = u.repo.CountOtpsByIdentifier(identifier, since)
+	if err != nil {
+		return err
+	}
+	if count >= otpMaxSendsPerIdentifier {
+		return &RateLimitError{Reason: "daily otp limit reached", RetryAfter: oldest.Add(otpSendWindow).Sub(now)}
+	}
+
+	latest, err := u.repo.GetLatestOtp(identifier, purpose)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if latest != nil && now.Sub(latest.CreatedAt) < otpResendCooldown {
+		return &RateLimitError{Reason: "please wait before requesting another otp", RetryAfter: latest.CreatedAt.Add(otpResendCooldown).Sub(now)}
+	}
+
+	return nil
+}
+
+// checkOtp validates code against the latest active OTP and consumes it on
+// success. Every call counts as an attempt, successful or not.
+func (u *Usecase) checkOtp(identifier, purpose, code string) (*domain.Otp, error) {
+	otp, err := u.verifyOtp(identifier, purpose, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.consumeOtp(otp); err != nil {
+		return nil, err
+	}
+	return otp, nil
+}
+
+// checkUserOtp is checkOtp for a code issued to the user, see
+// repo.GetActiveUserOtp
+func (u *Usecase) checkUserOtp(userID int, identifier, purpose, code string) (*domain.Otp, error) {
+	otp, err := u.repo.GetActiveUserOtp(userID, identifier, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOtp
+		}
+		return nil, err
+	}
+
+	if err := u.verifyOtpCode(otp, code); err != nil {
+		return nil, err
+	}
+	if err := u.consumeOtp(otp); err != nil {
+		return nil, err
+	}
+	return otp, nil
+}
+
+// verifyOtp is checkOtp without consuming the OTP, for callers that still
+// have to validate the rest of the request before using the code up.
+func (u *Usecase) verifyOtp(identifier, purpose, code string) (*domain.Otp, error) {
+	otp, err := u.repo.GetActiveOtp(identifier, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOtp
+		}
+		return nil, err
+	}
+
+	if err := u.verifyOtpCode(otp, code); err != nil {
+		return nil, err
+	}
+	return otp, nil
+}
+
+// verifyOtpCode counts an attempt against otp and checks code matches it
+func (u *Usecase) verifyOtpCode(otp *domain.Otp, code string) error {
+	if time.Now().After(otp.ExpiresAt) {
+		return ErrOtpExpired
+	}
+
+	ok, err := u.repo.IncrementOtpAttempts(otp.ID, otpMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOtpAttemptsExceeded
+	}
+
+	if !utils.CompareOTP(otp.CodeHash, otp.Identifier, otp.Purpose, code) {
+		return ErrInvalidOtp
+	}
+
+	return nil
+}
+
+// consumeOtp marks an OTP returned by verifyOtp as used. It fails if another
+// request used it first.
+func (u *Usecase) consumeOtp(otp *domain.Otp) error {
+	ok, err := u.repo.ConsumeOtp(otp.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidOtp
+	}
+	return nil
+}
+
+func (u *Usecase) VerifyOTP(data inbound.VerifyOtp) error {
+	email := utils.NormalizeIdentifier(data.Email)
+	if _, err := u.checkOtp(email, domain.OtpPurposeVerify, data.Otp); err != nil {
+		return err
+	}
+
+	return u.repo.VerifyUser(email)
+}
+
+// ResendOTP invalidates earlier verification codes and sends a new one. Unknown
+// identifiers and accounts that are already verified are accepted silently
+// and count towards the same cooldown and caps, so the endpoint cannot be used
+// to probe for accounts.
+func (u *Usecase) ResendOTP(data inbound.ResendOtp, ip string) error {
+	identifier := utils.NormalizeIdentifier(data.Identifier)
+	user, err := u.repo.GetUserByIdentifier(identifier)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if user != nil {
+		identifier = user.Email
+	}
+
+	if err := u.checkOtpSendLimits(identifier, domain.OtpPurposeVerify, ip); err != nil {
+		return err
+	}
+
+	if user == nil || user.IsVerified {
+		return u.recordOtpSend(identifier, domain.OtpPurposeVerify, ip)
+	}
+
+	return u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.InvalidateOtps(user.Email, domain.OtpPurposeVerify); err != nil {
+			return err
+		}
+
+		otp, _, err := tx.issueOtp(user.ID, user.Email, domain.OtpPurposeVerify, ip)
+		if err != nil {
+			return err
+		}
+
+		return tx.queueEmail(u.verificationEmail(user.Email, user.Locale, otp))
+	})
+}
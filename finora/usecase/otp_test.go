@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+// pastCooldown moves every stored OTP back beyond the resend cooldown
+func (e *testEnv) pastCooldown(t *testing.T) {
+	t.Helper()
+	err := e.db.Model(&domain.Otp{}).Where("1 = 1").Update("created_at", time.Now().Add(-otpResendCooldown-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wantRateLimit fails unless err is a RateLimitError for reason
+func wantRateLimit(t *testing.T, err error, reason string) *RateLimitError {
+	t.Helper()
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.Reason != reason {
+		t.Fatalf("error = %v, want rate limit %q", err, reason)
+	}
+	if rateErr.RetryAfter <= 0 {
+		t.Fatalf("retry after = %v, want a positive wait", rateErr.RetryAfter)
+	}
+	return rateErr
+}
+
+func TestResendOTPLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		identifier func(*testEnv, *testing.T) string
+		wantEmails int64
+	}{
+		{
+			name: "unverified account",
+			identifier: func(e *testEnv, t *testing.T) string {
+				user := e.createUser(t, "new@example.com")
+				e.db.Model(user).Update("is_verified", false)
+				return user.Email
+			},
+			wantEmails: otpMaxSendsPerIdentifier,
+		},
+		{
+			name:       "verified account",
+			identifier: func(e *testEnv, t *testing.T) string { return e.createUser(t, "done@example.com").Email },
+		},
+		{
+			name:       "unknown identifier",
+			identifier: func(*testEnv, *testing.T) string { return "nobody@example.com" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			resend := inbound.ResendOtp{Identifier: tt.identifier(env, t)}
+
+			if err := env.ResendOTP(resend, client.IP); err != nil {
+				t.Fatal(err)
+			}
+			wantRateLimit(t, env.ResendOTP(resend, client.IP), "please wait before requesting another otp")
+
+			for i := 1; i < otpMaxSendsPerIdentifier; i++ {
+				env.pastCooldown(t)
+				if err := env.ResendOTP(resend, client.IP); err != nil {
+					t.Fatalf("send %d: %v", i+1, err)
+				}
+			}
+			env.pastCooldown(t)
+			wantRateLimit(t, env.ResendOTP(resend, client.IP), "daily otp limit reached")
+
+			var emails int64
+			env.db.Model(&domain.OutboxEmail{}).Count(&emails)
+			if emails != tt.wantEmails {
+				t.Fatalf("queued %d emails, want %d", emails, tt.wantEmails)
+			}
+		})
+	}
+}
+
+func TestResendOTPPerIPCap(t *testing.T) {
+	env := newTestEnv(t)
+
+	for i := 0; i < otpMaxSendsPerIP; i++ {
+		if err := env.ResendOTP(inbound.ResendOtp{Identifier: fmt.Sprintf("user%d@example.com", i)}, client.IP); err != nil {
+			t.Fatalf("send %d: %v", i+1, err)
+		}
+	}
+	wantRateLimit(t, env.ResendOTP(inbound.ResendOtp{Identifier: "one-more@example.com"}, client.IP), "too many otp requests from this network")
+
+	if err := env.ResendOTP(inbound.ResendOtp{Identifier: "one-more@example.com"}, "198.51.100.7"); err != nil {
+		t.Fatalf("another network was throttled: %v", err)
+	}
+}
+
+func TestUnsentOTPCannotBeVerified(t *testing.T) {
+	env := newTestEnv(t)
+
+	if err := env.ResendOTP(inbound.ResendOtp{Identifier: "nobody@example.com"}, client.IP); err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range []string{"", "000000"} {
+		if _, err := env.checkOtp("nobody@example.com", domain.OtpPurposeVerify, code); !errors.Is(err, ErrInvalidOtp) {
+			t.Fatalf("code %q: error = %v, want ErrInvalidOtp", code, err)
+		}
+	}
+}
+
+func TestCheckOtp(t *testing.T) {
+	tests := []struct {
+		name         string
+		wrongGuesses int
+		expire       bool
+		purpose      string // purpose the code is checked for, if not the one it was issued for
+		wantErr      error
+	}{
+		{name: "correct code"},
+		{name: "correct code after wrong guesses", wrongGuesses: otpMaxAttempts - 1},
+		{name: "attempts exhausted", wrongGuesses: otpMaxAttempts, wantErr: ErrOtpAttemptsExceeded},
+		{name: "expired", expire: true, wantErr: ErrOtpExpired},
+		{name: "issued for another purpose", purpose: domain.OtpPurposePasswordReset, wantErr: ErrInvalidOtp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.createUser(t, "otp@example.com")
+			code := env.issueCode(t, user.ID, user.Email, domain.OtpPurposeVerify)
+			if tt.expire {
+				env.db.Model(&domain.Otp{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Second))
+			}
+			purpose := domain.OtpPurposeVerify
+			if tt.purpose != "" {
+				purpose = tt.purpose
+			}
+
+			for i := 0; i < tt.wrongGuesses; i++ {
+				if _, err := env.checkOtp(user.Email, purpose, wrongCode(code)); !errors.Is(err, ErrInvalidOtp) {
+					t.Fatalf("wrong guess %d: error = %v, want ErrInvalidOtp", i+1, err)
+				}
+			}
+
+			if _, err := env.checkOtp(user.Email, purpose, code); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			// A code works once
+			if _, err := env.checkOtp(user.Email, purpose, code); !errors.Is(err, ErrInvalidOtp) {
+				t.Fatalf("second use: error = %v, want ErrInvalidOtp", err)
+			}
+		})
+	}
+}
+
+func TestVerifyOTPVerifiesAccountOnce(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "verify@example.com")
+	env.db.Model(user).Update("is_verified", false)
+
+	code := env.issueCode(t, user.ID, user.Email, domain.OtpPurposeVerify)
+	if err := env.VerifyOTP(inbound.VerifyOtp{Email: "Verify@Example.com", Otp: code}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := env.repo.GetUserByID(user.ID); !stored.IsVerified {
+		t.Fatal("account not verified")
+	}
+	if err := env.VerifyOTP(inbound.VerifyOtp{Email: user.Email, Otp: code}); !errors.Is(err, ErrInvalidOtp) {
+		t.Fatalf("replayed code: error = %v, want ErrInvalidOtp", err)
+	}
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/policy"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidResetToken  = errors.New("invalid or expired reset link")
+	ErrResetProofRequired = errors.New("either token or identifier and otp are required")
+)
+
+// ForgotPassword emails a reset code and a signed reset link, or texts just
+// the code when the user asked with their phone number. It behaves the same
+// whether or not the account exists, so callers learn nothing from it.
+func (u *Usecase) ForgotPassword(data inbound.ForgotPassword, ip string) error {
+	data.Identifier = utils.NormalizeIdentifier(data.Identifier)
+	user, err := u.repo.GetUserByIdentifier(data.Identifier)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	recipient, bySMS := u.resetRecipient(user, data.Identifier)
+	if recipient == "" {
+		return nil
+	}
+
+	err = u.checkOtpSendLimits(recipient, domain.OtpPurposePasswordReset, ip)
+	if err == nil && bySMS {
+		err = u.checkSMSLimits(recipient)
+	}
+	if err != nil {
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			log.Printf("password reset for user %d throttled: %v", user.ID, err)
+			return nil
+		}
+		return err
+	}
+
+	var code string
+	err = u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.InvalidateOtps(recipient, domain.OtpPurposePasswordReset); err != nil {
+			return err
+		}
+
+		var otp *domain.Otp
+		var err error
+		code, otp, err = tx.issueOtp(user.ID, recipient, domain.OtpPurposePasswordReset, ip)
+		if err != nil || bySMS {
+			return err
+		}
+
+		token, err := utils.GenerateActionToken(user.ID, utils.TokenTypePasswordReset, strconv.Itoa(otp.ID), otp.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		resetURL := fmt.Sprintf("%s/reset-password?token=%s", strings.TrimRight(u.mail.URL, "/"), url.QueryEscape(token))
+
+		return tx.queueEmail(u.templates.Render(mailer.TemplateReset, user.Locale, user.Email, mailer.ResetData{
+			Otp:      code,
+			ResetURL: resetURL,
+		}))
+	})
+	if err == nil && bySMS {
+		err = u.sendOtpSMS(recipient, user.Locale, domain.OtpPurposePasswordReset, code)
+	}
+	if errors.Is(err, ErrSMSFailed) {
+		log.Printf("password reset for user %d not delivered: %v", user.ID, err)
+		return nil
+	}
+	return err
+}
+
+// resetRecipient picks where a reset code goes: the phone number when the
+// user asked with it and SMS is available, the account email otherwise. The
+// code is stored under the same identifier, so ResetPassword must resolve it
+// the same way. It returns "" when the account has no usable address.
+func (u *Usecase) resetRecipient(user *domain.User, identifier string) (string, bool) {
+	if !utils.IsEmail(identifier) && identifier == user.Phone && u.smsEnabled() {
+		return user.Phone, true
+	}
+	return user.Email, false
+}
+
+// ResetPassword sets a new password after checking either the reset link
+// token or the emailed code. Every existing session is revoked on success.
+// The new password is only checked against the policy once the link or code
+// has been verified, and the link or code is used up only after the password
+// passed, so a rejected password does not cost the user their reset.
+func (u *Usecase) ResetPassword(data inbound.ResetPassword, client inbound.Client) error {
+	var user *domain.User
+	var otp *domain.Otp
+	data.Identifier = utils.NormalizeIdentifier(data.Identifier)
+
+	switch {
+	case data.Token != "":
+		var err error
+		otp, err = u.resetTokenOtp(data.Token)
+		if err != nil {
+			return err
+		}
+		user, err = u.repo.GetUserByID(otp.UserID)
+		if err != nil {
+			return ErrInvalidResetToken
+		}
+	case data.Identifier != "" && data.Otp != "":
+		var err error
+		user, err = u.repo.GetUserByIdentifier(data.Identifier)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidOtp
+			}
+			return err
+		}
+		recipient, _ := u.resetRecipient(user, data.Identifier)
+		otp, err = u.verifyOtp(recipient, domain.OtpPurposePasswordReset, data.Otp)
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrResetProofRequired
+	}
+
+	if err := u.checkNewPassword(user, data.NewPassword); err != nil {
+		return err
+	}
+
+	// Using up the code, setting the password and signing out every session
+	// happen together, so a failure part way cannot leave a spent code with
+	// the old password or the new password with old sessions still alive.
+	err := u.inTx(func(tx *Usecase) error {
+		if err := tx.consumeOtp(otp); err != nil {
+			if data.Token != "" && errors.Is(err, ErrInvalidOtp) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		if err := tx.repo.UpdatePassword(user.ID, data.NewPassword); err != nil {
+			return err
+		}
+		for _, identifier := range []string{user.Email, user.Phone} {
+			if identifier == "" {
+				continue
+			}
+			if err := tx.repo.InvalidateOtps(identifier, domain.OtpPurposePasswordReset); err != nil {
+				return err
+			}
+		}
+
+		return tx.repo.RevokeAllSessions(user.ID, 0)
+	})
+	if err != nil {
+		return err
+	}
+
+	u.auditSelf(user.ID, domain.AuditPasswordReset, client, nil)
+	u.notifySecurity(user.ID, "Password reset", "Your password was reset and all sessions were signed out.",
+		map[string]any{"event": domain.AuditPasswordReset})
+	return nil
+}
+
+// resetTokenOtp verifies a reset link and returns the still unused OTP it
+// refers to. Consuming that OTP makes both the link and its code unusable.
+func (u *Usecase) resetTokenOtp(token string) (*domain.Otp, error) {
+	claims, err := utils.ParseToken(token, utils.TokenTypePasswordReset)
+	if err != nil {
+		return nil, ErrInvalidResetToken
+	}
+
+	otpID, err := strconv.Atoi(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidResetToken
+	}
+
+	otp, err := u.repo.GetOtpByID(otpID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidResetToken
+		}
+		return nil, err
+	}
+	if otp.Purpose != domain.OtpPurposePasswordReset || otp.UserID != claims.UserID || otp.Consumed || time.Now().After(otp.ExpiresAt) {
+		return nil, ErrInvalidResetToken
+	}
+
+	return otp, nil
+}
+
+// checkNewPassword applies the password policy to a password the user is
+// about to set.
+func (u *Usecase) checkNewPassword(user *domain.User, password string) error {
+	return u.passwords.Check(password, policy.Account{Email: user.Email, Username: user.Username})
+}
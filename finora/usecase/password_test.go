@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+const newPassword = "a whole new password here"
+
+func TestResetPasswordIsAllOrNothing(t *testing.T) {
+	tests := []struct {
+		name    string
+		breakDB func(*testEnv)
+		wantErr bool
+	}{
+		{name: "reset completes"},
+		{
+			name:    "signing sessions out fails",
+			breakDB: func(e *testEnv) { e.db.Migrator().DropTable(&domain.Session{}) },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			user := env.createUser(t, "reset@example.com")
+			session := env.newSession(t, user, time.Now())
+			code := env.issueCode(t, user.ID, user.Email, domain.OtpPurposePasswordReset)
+			if tt.breakDB != nil {
+				tt.breakDB(env)
+			}
+
+			err := env.ResetPassword(inbound.ResetPassword{Identifier: user.Email, Otp: code, NewPassword: newPassword}, client)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+
+			stored, err := env.repo.GetUserByID(user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changed, _ := env.repo.CheckPassword(stored, newPassword)
+			var unused int64
+			env.db.Model(&domain.Otp{}).Where("consumed = ?", false).Count(&unused)
+
+			if tt.wantErr {
+				if changed || unused != 1 {
+					t.Fatalf("failed reset left password changed=%v and %d unused codes, want nothing changed", changed, unused)
+				}
+				return
+			}
+			if !changed || unused != 0 {
+				t.Fatalf("password changed=%v and %d unused codes, want the new password and the code spent", changed, unused)
+			}
+			if got, _ := env.repo.GetSession(session.ID); got.RevokedAt == nil {
+				t.Fatal("session still active after the reset")
+			}
+		})
+	}
+}
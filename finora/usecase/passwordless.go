@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOtpUndeliverable  = errors.New("no way to deliver an otp to this phone number, use an email address instead")
+	ErrInvalidIdentifier = errors.New("identifier must be an email address or a phone number in international format, e.g. +14155552671")
+)
+
+// SendLoginOTP sends a one-time login code to an email address or phone
+// number. Identifiers without an account are accepted; the account is created
+// when the code is verified. The answer is the same whether or not the code
+// could be delivered, so callers learn nothing about which numbers have an
+// account. Anything but an email address or E.164 phone number is refused
+// with ErrInvalidIdentifier.
+func (u *Usecase) SendLoginOTP(data inbound.SendOtp, ip string) error {
+	identifier := utils.NormalizeIdentifier(data.Identifier)
+	if !utils.ValidIdentifier(identifier) {
+		return ErrInvalidIdentifier
+	}
+	if err := u.checkOtpSendLimits(identifier, domain.OtpPurposeLogin, ip); err != nil {
+		return err
+	}
+
+	user, err := u.repo.GetUserByIdentifier(identifier)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Phone numbers get the code by text message. Without SMS a phone number
+	// only works when it belongs to an account that also has an email address.
+	bySMS := !utils.IsEmail(identifier) && u.smsEnabled()
+	recipient := identifier
+	if bySMS {
+		if err := u.checkSMSLimits(identifier); err != nil {
+			return err
+		}
+	} else if !utils.IsEmail(identifier) {
+		if user == nil || user.Email == "" {
+			return nil
+		}
+		recipient = user.Email
+	}
+
+	var userID int
+	var locale string
+	if user != nil {
+		userID, locale = user.ID, user.Locale
+	}
+
+	var code string
+	err = u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.InvalidateOtps(identifier, domain.OtpPurposeLogin); err != nil {
+			return err
+		}
+
+		var err error
+		code, _, err = tx.issueOtp(userID, identifier, domain.OtpPurposeLogin, ip)
+		if err != nil || bySMS {
+			return err
+		}
+		return tx.queueEmail(u.verificationEmail(recipient, locale, code))
+	})
+	if err != nil || !bySMS {
+		return err
+	}
+	return u.sendOtpSMS(recipient, locale, domain.OtpPurposeLogin, code)
+}
+
+// VerifyLoginOTP checks a login code and signs the user in, creating the
+// account on first use. Like Login, wrong codes are throttled per account and
+// per client address, and users with two-factor authentication get an
+// MFAChallenge instead of tokens.
+func (u *Usecase) VerifyLoginOTP(data inbound.VerifyLoginOtp, client inbound.Client) (*response.AuthResponse, *response.MFAChallenge, error) {
+	identifier := utils.NormalizeIdentifier(data.Identifier)
+	if !utils.ValidIdentifier(identifier) {
+		return nil, nil, ErrInvalidIdentifier
+	}
+	if err := u.checkLoginThrottle(ipThrottleKey(client.IP), "too many failed logins from this network"); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := u.repo.GetUserByIdentifier(identifier)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+	if user != nil {
+		if err := u.checkLoginThrottle(accountThrottleKey(user.ID), "account temporarily locked after too many failed logins"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if _, err := u.checkOtp(identifier, domain.OtpPurposeLogin, data.Otp); err != nil {
+		if errors.Is(err, ErrInvalidOtp) || errors.Is(err, ErrOtpExpired) || errors.Is(err, ErrOtpAttemptsExceeded) {
+			u.recordLoginFailure(user, client)
+		}
+		return nil, nil, err
+	}
+
+	if user == nil {
+		user = &domain.User{IsVerified: true}
+		if utils.IsEmail(identifier) {
+			user.Email = identifier
+		} else {
+			user.Phone = identifier
+		}
+		if err := u.repo.CreateUser(user); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if err := u.repo.ResetLoginThrottle(accountThrottleKey(user.ID)); err != nil {
+			return nil, nil, err
+		}
+		if !user.IsVerified {
+			// Whoever signed up never proved they own this address, so the
+			// password they chose must not keep working for its real owner.
+			if err := u.repo.ClaimUnverifiedUser(user.ID); err != nil {
+				return nil, nil, err
+			}
+			user.IsVerified, user.Password = true, ""
+		}
+	}
+
+	if user.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	mfa, err := u.twoFactorEnabled(user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mfa {
+		challenge, err := u.mfaChallenge(user.ID)
+		return nil, challenge, err
+	}
+
+	res, err := u.startSession(user, client, loginMethodOTP)
+	return res, nil, err
+}
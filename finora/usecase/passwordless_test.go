@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+var codePattern = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+func TestLoginOTPRejectsInvalidIdentifier(t *testing.T) {
+	for _, identifier := range []string{"hello", "+1-800-FLOWERS", "ada@", "Ada <ada@example.com>", "12345"} {
+		t.Run(identifier, func(t *testing.T) {
+			env := newTestEnv(t)
+
+			if err := env.SendLoginOTP(inbound.SendOtp{Identifier: identifier}, client.IP); !errors.Is(err, ErrInvalidIdentifier) {
+				t.Fatalf("send error = %v, want ErrInvalidIdentifier", err)
+			}
+			_, _, err := env.VerifyLoginOTP(inbound.VerifyLoginOtp{Identifier: identifier, Otp: "123456"}, client)
+			if !errors.Is(err, ErrInvalidIdentifier) {
+				t.Fatalf("verify error = %v, want ErrInvalidIdentifier", err)
+			}
+
+			var otps int64
+			env.db.Model(&domain.Otp{}).Count(&otps)
+			if otps != 0 || len(env.sms.Messages()) != 0 {
+				t.Fatalf("stored %d codes and sent %d texts for an invalid identifier", otps, len(env.sms.Messages()))
+			}
+		})
+	}
+}
+
+func TestLoginOTPCanonicalizesPhoneNumbers(t *testing.T) {
+	env := newTestEnv(t)
+
+	if err := env.SendLoginOTP(inbound.SendOtp{Identifier: "14155552671"}, client.IP); err != nil {
+		t.Fatal(err)
+	}
+	msg, sent := env.sms.Last("+14155552671")
+	if !sent {
+		t.Fatal("code was not texted to the E.164 number")
+	}
+
+	code := codePattern.FindString(msg.Body)
+	if _, _, err := env.VerifyLoginOTP(inbound.VerifyLoginOtp{Identifier: "+1 (415) 555-2671", Otp: code}, client); err != nil {
+		t.Fatal(err)
+	}
+	user, err := env.repo.GetUserByIdentifier("+14155552671")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Phone != "+14155552671" {
+		t.Fatalf("phone stored as %q", user.Phone)
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/utils"
+)
+
+// patDisplayLength is how much of a token is kept in clear to identify it
+const patDisplayLength = len(utils.PATPrefix) + 6
+
+var (
+	ErrInvalidScope = errors.New("unknown scope")
+	ErrPATNotFound  = errors.New("access token not found")
+)
+
+// CreatePAT issues a personal access token. The plain token is returned only
+// from this call; afterwards only its hash is known.
+func (u *Usecase) CreatePAT(userID int, data inbound.CreatePAT, client inbound.Client) (*response.CreatedPAT, error) {
+	for _, scope := range data.Scopes {
+		if !slices.Contains(domain.PATScopes, scope) {
+			return nil, fmt.Errorf("%w %q", ErrInvalidScope, scope)
+		}
+	}
+
+	plain := utils.GeneratePAT()
+	token := &domain.PersonalAccessToken{
+		UserID:    userID,
+		Name:      data.Name,
+		Prefix:    plain[:patDisplayLength],
+		TokenHash: utils.HashSecret(plain),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(data.Scopes))),
+	}
+	if data.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, data.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := u.repo.CreatePAT(token); err != nil {
+		return nil, err
+	}
+
+	u.auditSelf(userID, domain.AuditPATCreate, client, map[string]any{
+		"token_id": token.ID,
+		"name":     token.Name,
+		"scopes":   token.Scopes,
+	})
+	return &response.CreatedPAT{PAT: toPATResponse(token), Token: plain}, nil
+}
+
+func (u *Usecase) ListPATs(userID int) ([]response.PAT, error) {
+	tokens, err := u.repo.ListPATs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]response.PAT, 0, len(tokens))
+	for i := range tokens {
+		res = append(res, toPATResponse(&tokens[i]))
+	}
+	return res, nil
+}
+
+func (u *Usecase) RevokePAT(userID, id int, client inbound.Client) error {
+	revoked, err := u.repo.RevokePAT(userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrPATNotFound
+	}
+
+	u.auditSelf(userID, domain.AuditPATRevoke, client, map[string]any{"token_id": id})
+	return nil
+}
+
+func toPATResponse(token *domain.PersonalAccessToken) response.PAT {
+	return response.PAT{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
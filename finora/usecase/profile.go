@@ -0,0 +1,329 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/sms"
+	"github.com/ayyoob-k-a/finora/utils"
+)
+
+var (
+	ErrNothingToUpdate   = errors.New("no profile fields to update")
+	ErrContactInUse      = errors.New("this email or phone number is already used by another account")
+	ErrIncorrectPassword = errors.New("current password is incorrect")
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+)
+
+func (u *Usecase) GetProfile(userID int) (*response.Profile, error) {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := toProfile(user)
+	return &profile, nil
+}
+
+// UpdateProfile applies username and locale changes straight away. A new email or phone
+// is only recorded once the code sent to it is confirmed, so nothing is
+// changed if any new address is unusable. Changing either needs a recently
+// authenticated session, since whoever controls them can reset the password.
+// The changes and the codes are stored in one transaction, so a failure
+// leaves the profile as it was.
+func (u *Usecase) UpdateProfile(userID, sessionID int, data inbound.UpdateProfile, client inbound.Client) (*response.ProfileUpdate, error) {
+	if data.Username == nil && data.Email == nil && data.Phone == nil && data.Locale == nil {
+		return nil, ErrNothingToUpdate
+	}
+	if data.Locale != nil && !u.templates.SupportsLocale(*data.Locale) {
+		return nil, ErrUnsupportedLocale
+	}
+
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	type contactChange struct {
+		identifier string
+		purpose    string
+	}
+	var changes []contactChange
+	if data.Email != nil {
+		email := utils.NormalizeIdentifier(*data.Email)
+		if email != user.Email {
+			changes = append(changes, contactChange{email, domain.OtpPurposeChangeEmail})
+		}
+	}
+	if data.Phone != nil {
+		phone := utils.NormalizeIdentifier(*data.Phone)
+		if phone != user.Phone {
+			changes = append(changes, contactChange{phone, domain.OtpPurposeChangePhone})
+		}
+	}
+
+	if len(changes) > 0 {
+		if err := u.requireRecentAuth(sessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, change := range changes {
+		if err := u.checkContactAvailable(change.identifier, user.ID); err != nil {
+			return nil, err
+		}
+		if err := u.checkOtpSendLimits(change.identifier, change.purpose, client.IP); err != nil {
+			return nil, err
+		}
+		if !utils.IsEmail(change.identifier) {
+			if !u.smsEnabled() {
+				return nil, ErrOtpUndeliverable
+			}
+			if err := u.checkSMSLimits(change.identifier); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	username, locale := user.Username, user.Locale
+	if data.Username != nil {
+		username = *data.Username
+	}
+	if data.Locale != nil {
+		locale = mailer.NormalizeLocale(*data.Locale)
+	}
+
+	// Codes for phone numbers are texted once the transaction has committed
+	codes := make([]string, len(changes))
+	err = u.inTx(func(tx *Usecase) error {
+		if username != user.Username {
+			if err := tx.repo.UpdateUsername(user.ID, username); err != nil {
+				return err
+			}
+		}
+		if locale != user.Locale {
+			if err := tx.repo.UpdateLocale(user.ID, locale); err != nil {
+				return err
+			}
+		}
+
+		for i, change := range changes {
+			// Only this account's earlier codes; another account may be
+			// claiming the same address
+			if err := tx.repo.InvalidateUserOtps(user.ID, change.identifier, change.purpose); err != nil {
+				return err
+			}
+			code, _, err := tx.issueOtp(user.ID, change.identifier, change.purpose, client.IP)
+			if err != nil {
+				return err
+			}
+			codes[i] = code
+
+			if utils.IsEmail(change.identifier) {
+				if err := tx.queueEmail(u.verificationEmail(change.identifier, locale, code)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if username != user.Username {
+		u.auditSelf(user.ID, domain.AuditProfileUpdate, client, map[string]any{
+			"username": map[string]string{"from": utils.MaskIdentifier(user.Username), "to": utils.MaskIdentifier(username)},
+		})
+		user.Username = username
+	}
+	if locale != user.Locale {
+		u.auditSelf(user.ID, domain.AuditProfileUpdate, client, map[string]any{
+			"locale": map[string]string{"from": user.Locale, "to": locale},
+		})
+		user.Locale = locale
+	}
+
+	res := &response.ProfileUpdate{VerificationSentTo: []string{}}
+	for i, change := range changes {
+		if !utils.IsEmail(change.identifier) {
+			if err := u.sendOtpSMS(change.identifier, user.Locale, change.purpose, codes[i]); err != nil {
+				return nil, err
+			}
+		}
+		res.VerificationSentTo = append(res.VerificationSentTo, change.identifier)
+	}
+
+	res.Profile = toProfile(user)
+	return res, nil
+}
+
+// ConfirmContactChange switches the account to a new email or phone once the
+// code sent to it is confirmed, and warns the old address.
+func (u *Usecase) ConfirmContactChange(userID int, data inbound.ConfirmContactChange, client inbound.Client) (*response.Profile, error) {
+	identifier := utils.NormalizeIdentifier(data.Identifier)
+	purpose, field, action := domain.OtpPurposeChangePhone, "phone", domain.AuditPhoneChange
+	if utils.IsEmail(identifier) {
+		purpose, field, action = domain.OtpPurposeChangeEmail, "email", domain.AuditEmailChange
+	}
+
+	if _, err := u.checkUserOtp(userID, identifier, purpose, data.Otp); err != nil {
+		return nil, err
+	}
+
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.checkContactAvailable(identifier, user.ID); err != nil {
+		return nil, err
+	}
+
+	old := user.Email
+	if purpose == domain.OtpPurposeChangePhone {
+		old = user.Phone
+	}
+	textTo := u.contactChangedTextRecipient(user, field)
+	err = u.inTx(func(tx *Usecase) error {
+		var err error
+		if purpose == domain.OtpPurposeChangeEmail {
+			err = tx.repo.UpdateEmail(user.ID, identifier)
+		} else {
+			err = tx.repo.UpdatePhone(user.ID, identifier)
+		}
+		if err != nil || textTo != "" {
+			return err
+		}
+
+		return tx.queueContactChangedNotice(user, field, identifier)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if textTo != "" {
+		if err := u.sendSMS(sms.ContactChanged(textTo, user.Locale, utils.MaskIdentifier(identifier)), domain.SMSPurposeContactChanged); err != nil {
+			log.Printf("contact change notice for user %d not delivered: %v", user.ID, err)
+		}
+	}
+	if purpose == domain.OtpPurposeChangeEmail {
+		user.Email = identifier
+	} else {
+		user.Phone = identifier
+	}
+
+	u.auditSelf(user.ID, action, client, map[string]any{
+		"from": utils.MaskIdentifier(old),
+		"to":   utils.MaskIdentifier(identifier),
+	})
+	u.notifySecurity(user.ID, "Contact details changed", "The "+field+" on your account was changed.",
+		map[string]any{"event": action, "field": field, "to": utils.MaskIdentifier(identifier)})
+
+	profile := toProfile(user)
+	return &profile, nil
+}
+
+// contactChangedTextRecipient returns the number to text about a change to
+// field, or "" when the notice goes by email instead. A changed phone number
+// is reported to the old number, and a changed email to the phone number
+// only when there was no email before.
+func (u *Usecase) contactChangedTextRecipient(user *domain.User, field string) string {
+	if !u.smsEnabled() || user.Phone == "" {
+		return ""
+	}
+	if field == "email" && user.Email != "" {
+		return ""
+	}
+	return user.Phone
+}
+
+// queueContactChangedNotice warns the account email about a change to field,
+// email or phone. user still holds the details from before the change, so a
+// changed email is reported to the old address. Nothing is sent when the
+// account had no email.
+func (u *Usecase) queueContactChangedNotice(user *domain.User, field, newValue string) error {
+	if user.Email == "" {
+		return nil
+	}
+
+	return u.queueEmail(u.templates.Render(mailer.TemplateContactChanged, user.Locale, user.Email, mailer.ContactChangedData{
+		Field:     field,
+		NewValue:  utils.MaskIdentifier(newValue),
+		ChangedAt: mailer.FormatTime(time.Now()),
+	}))
+}
+
+func (u *Usecase) checkContactAvailable(identifier string, userID int) error {
+	taken, err := u.repo.IdentifierTaken(identifier, userID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrContactInUse
+	}
+	return nil
+}
+
+// ChangePassword sets a new password after checking the current one, and
+// signs out every other session. Wrong guesses count towards the account
+// lockout like failed logins.
+func (u *Usecase) ChangePassword(userID, sessionID int, data inbound.ChangePassword, client inbound.Client) error {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := u.checkLoginThrottle(accountThrottleKey(user.ID), "account temporarily locked after too many failed attempts"); err != nil {
+		return err
+	}
+
+	// Accounts created through social or OTP login have no password yet. They
+	// may set one without, but only on a recently authenticated session.
+	if user.Password != "" {
+		ok, err := u.repo.CheckPassword(user, data.CurrentPassword)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			u.recordLoginFailure(user, client)
+			return ErrIncorrectPassword
+		}
+	} else if err := u.requireRecentAuth(sessionID); err != nil {
+		return err
+	}
+
+	if err := u.checkNewPassword(user, data.NewPassword); err != nil {
+		return err
+	}
+	if err := u.repo.UpdatePassword(user.ID, data.NewPassword); err != nil {
+		return err
+	}
+	if err := u.repo.RevokeAllSessions(user.ID, sessionID); err != nil {
+		return err
+	}
+
+	u.auditSelf(user.ID, domain.AuditPasswordChange, client, nil)
+	u.notifySecurity(user.ID, "Password changed", "Your password was changed and your other sessions were signed out.",
+		map[string]any{"event": domain.AuditPasswordChange})
+	return nil
+}
+
+func toProfile(user *domain.User) response.Profile {
+	return response.Profile{
+		ID:          user.ID,
+		Email:       user.Email,
+		Phone:       user.Phone,
+		Username:    user.Username,
+		Role:        user.Role,
+		Locale:      user.Locale,
+		IsVerified:  user.IsVerified,
+		HasPassword: user.Password != "",
+		CreatedAt:   user.CreatedAt,
+
+		DeletionScheduledAt: user.DeletionScheduledAt,
+	}
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+// emailedCode returns the code in the last email queued for to
+func (e *testEnv) emailedCode(t *testing.T, to string) string {
+	t.Helper()
+	var email domain.OutboxEmail
+	if err := e.db.Where("recipient = ?", to).Order("id DESC").First(&email).Error; err != nil {
+		t.Fatal(err)
+	}
+	code := codePattern.FindString(email.Text)
+	if code == "" {
+		t.Fatalf("no code in %q", email.Text)
+	}
+	return code
+}
+
+func TestContactChangeCodesArePerAccount(t *testing.T) {
+	env := newTestEnv(t)
+	const claimed = "shared@example.com"
+	alice := env.createUser(t, "alice@example.com")
+	bob := env.createUser(t, "bob@example.com")
+	aliceSession := env.newSession(t, alice, time.Now())
+	bobSession := env.newSession(t, bob, time.Now())
+	email := claimed
+
+	if _, err := env.UpdateProfile(alice.ID, aliceSession.ID, inbound.UpdateProfile{Email: &email}, client); err != nil {
+		t.Fatal(err)
+	}
+	aliceCode := env.emailedCode(t, claimed)
+
+	env.pastCooldown(t)
+	if _, err := env.UpdateProfile(bob.ID, bobSession.ID, inbound.UpdateProfile{Email: &email}, client); err != nil {
+		t.Fatal(err)
+	}
+
+	// Bob asking for the same address neither spends nor shadows Alice's code
+	profile, err := env.ConfirmContactChange(alice.ID, inbound.ConfirmContactChange{Identifier: claimed, Otp: aliceCode}, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Email != claimed {
+		t.Fatalf("email = %q, want %q", profile.Email, claimed)
+	}
+}
+
+func TestUpdateProfileIsAllOrNothing(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "profile@example.com")
+	session := env.newSession(t, user, time.Now())
+	username, locale, email := "renamed", "hi", "new@example.com"
+
+	// Queueing the verification email fails, so nothing may change
+	env.db.Migrator().DropTable(&domain.OutboxEmail{})
+	_, err := env.UpdateProfile(user.ID, session.ID, inbound.UpdateProfile{Username: &username, Locale: &locale, Email: &email}, client)
+	if err == nil || errors.Is(err, ErrUnsupportedLocale) {
+		t.Fatalf("error = %v, want the transaction to fail", err)
+	}
+
+	stored, err := env.repo.GetUserByID(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Username != user.Username || stored.Locale != user.Locale {
+		t.Fatalf("profile changed to %q/%q by a failed update", stored.Username, stored.Locale)
+	}
+	var codes int64
+	env.db.Model(&domain.Otp{}).Count(&codes)
+	if codes != 0 {
+		t.Fatalf("%d codes stored by a failed update", codes)
+	}
+}
@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/policy"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRole   = errors.New("unknown role")
+	ErrUserNotFound  = errors.New("user not found")
+	ErrLastAdmin     = errors.New("cannot remove the last admin")
+	ErrBootstrapUser = errors.New("bootstrap admin does not exist and no password was given to create it")
+
+	ErrBootstrapUnverified = errors.New("bootstrap admin account exists but its email is not verified")
+)
+
+// SetUserRole assigns a role to a user. The last remaining admin cannot be
+// demoted, so the system always has someone able to manage roles. The admins
+// are counted and the role changed under a lock on the admin rows, so two
+// admins demoting each other at once cannot leave none.
+func (u *Usecase) SetUserRole(actorID, userID int, role string, client inbound.Client) error {
+	if !slices.Contains(domain.Roles, role) {
+		return ErrInvalidRole
+	}
+
+	var user *domain.User
+	var changed bool
+	err := u.inTx(func(tx *Usecase) error {
+		admins, err := tx.repo.LockAdmins()
+		if err != nil {
+			return err
+		}
+
+		user, err = tx.repo.GetUserByID(userID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if user.Role == role {
+			return nil
+		}
+		if user.Role == domain.RoleAdmin && admins <= 1 {
+			return ErrLastAdmin
+		}
+
+		ok, err := tx.repo.SetUserRole(userID, role)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrUserNotFound
+		}
+		changed = true
+		return nil
+	})
+	if err != nil || !changed {
+		return err
+	}
+
+	u.auditUserAction(actorID, domain.AuditAdminSetRole, userID, client, map[string]any{
+		"from": user.Role,
+		"to":   role,
+	})
+	return nil
+}
+
+// BootstrapAdmin makes sure at least one admin exists. When there is none it
+// promotes the configured account, creating it first if needed. An existing
+// account is only promoted once its owner has verified the email address;
+// otherwise whoever registered the address first would become admin. It does
+// nothing once an admin exists or if no bootstrap email is configured.
+func (u *Usecase) BootstrapAdmin(cfg configs.Admin) error {
+	email := utils.NormalizeIdentifier(cfg.Email)
+	if email == "" {
+		return nil
+	}
+
+	var promoted bool
+	err := u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.LockAdminBootstrap(); err != nil {
+			return err
+		}
+
+		admins, err := tx.repo.CountUsersByRole(domain.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins > 0 {
+			return nil
+		}
+
+		var userID int
+		user, err := tx.repo.GetUserByIdentifier(email)
+		switch {
+		case err == nil:
+			if !user.IsVerified {
+				return ErrBootstrapUnverified
+			}
+			userID = user.ID
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if cfg.Password == "" {
+				return ErrBootstrapUser
+			}
+			if err := tx.passwords.Check(cfg.Password, policy.Account{Email: email}); err != nil {
+				return fmt.Errorf("bootstrap admin: %w", err)
+			}
+			userID, err = tx.repo.Signup(domain.User{Email: email, Password: cfg.Password, Username: "admin", IsVerified: true})
+			if err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+
+		if _, err := tx.repo.SetUserRole(userID, domain.RoleAdmin); err != nil {
+			return err
+		}
+		promoted = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if promoted {
+		log.Printf("bootstrap: granted admin role to %s", email)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ayyoob-k-a/finora/domain"
+)
+
+func TestSetUserRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		admins   int
+		target   int // index of the user to change; the first admins users are admins
+		role     string
+		wantErr  error
+		wantRole string
+	}{
+		{name: "promote a user", admins: 1, target: 1, role: domain.RoleAdmin, wantRole: domain.RoleAdmin},
+		{name: "demote one of two admins", admins: 2, target: 1, role: domain.RoleUser, wantRole: domain.RoleUser},
+		{name: "demote the last admin", admins: 1, target: 0, role: domain.RoleUser, wantErr: ErrLastAdmin, wantRole: domain.RoleAdmin},
+		{name: "unknown role", admins: 1, target: 1, role: "owner", wantErr: ErrInvalidRole, wantRole: domain.RoleUser},
+		{name: "unknown user", admins: 1, target: -1, role: domain.RoleAdmin, wantErr: ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			var users []*domain.User
+			for i, email := range []string{"a@example.com", "b@example.com"} {
+				user := env.createUser(t, email)
+				if i < tt.admins {
+					env.db.Model(user).Update("role", domain.RoleAdmin)
+				}
+				users = append(users, user)
+			}
+
+			targetID := 999
+			if tt.target >= 0 {
+				targetID = users[tt.target].ID
+			}
+			if err := env.SetUserRole(users[0].ID, targetID, tt.role, client); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.target < 0 {
+				return
+			}
+			if got, _ := env.repo.GetUserByID(targetID); got.Role != tt.wantRole {
+				t.Fatalf("role = %q, want %q", got.Role, tt.wantRole)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+)
+
+var (
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrReauthRequired      = errors.New("confirm your identity again to make this change")
+	ErrReauthProofRequired = errors.New("one of password, otp or code is required")
+)
+
+// reauthWindow is how long after signing in or re-authenticating a session
+// may make sensitive changes
+const reauthWindow = 10 * time.Minute
+
+// How a session was signed in, recorded in the audit log
+const (
+	loginMethodPassword = "password"
+	loginMethodOTP      = "otp"
+	loginMethodOIDC     = "oidc"
+	loginMethodMFA      = "mfa"
+)
+
+// startSession records a new signed-in device and issues its first token pair
+func (u *Usecase) startSession(user *domain.User, client inbound.Client, method string) (*response.AuthResponse, error) {
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	device := client.Device
+	if device == "" {
+		device = "unknown"
+	}
+
+	now := time.Now()
+	session := &domain.Session{
+		UserID:     user.ID,
+		Device:     device,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		LastSeenAt: now,
+
+		AuthenticatedAt: &now,
+	}
+	if err := u.repo.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	u.auditSelf(user.ID, domain.AuditLogin, client, map[string]any{
+		"method":     method,
+		"session_id": session.ID,
+	})
+
+	return u.issueTokens(user, session.ID, "", "")
+}
+
+func (u *Usecase) ListSessions(userID, currentSessionID int) ([]response.Session, error) {
+	sessions, err := u.repo.ListActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]response.Session, 0, len(sessions))
+	for _, s := range sessions {
+		res = append(res, response.Session{
+			ID:         s.ID,
+			Device:     s.Device,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.ID == currentSessionID,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeSession ends one of the user's sessions. Logging out is revoking the
+// current session.
+func (u *Usecase) RevokeSession(userID, sessionID, currentSessionID int, client inbound.Client) error {
+	revoked, err := u.repo.RevokeSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+
+	action := domain.AuditSessionRevoke
+	if sessionID == currentSessionID {
+		action = domain.AuditLogout
+	}
+	u.auditSelf(userID, action, client, map[string]any{"session_id": sessionID})
+	return nil
+}
+
+// LogoutAll revokes every session of the user, including the current one
+func (u *Usecase) LogoutAll(userID int, client inbound.Client) error {
+	if err := u.repo.RevokeAllSessions(userID, 0); err != nil {
+		return err
+	}
+
+	u.auditSelf(userID, domain.AuditLogoutAll, client, nil)
+	return nil
+}
+
+// SendReauthOTP sends a code the signed-in user can pass to Reauthenticate.
+// It goes to the account email, or by text message to the phone number when
+// the account has no email.
+func (u *Usecase) SendReauthOTP(userID int, ip string) error {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	recipient, bySMS := u.reauthRecipient(user)
+	if recipient == "" {
+		return ErrOtpUndeliverable
+	}
+	if err := u.checkOtpSendLimits(recipient, domain.OtpPurposeReauth, ip); err != nil {
+		return err
+	}
+	if bySMS {
+		if err := u.checkSMSLimits(recipient); err != nil {
+			return err
+		}
+	}
+
+	var code string
+	err = u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.InvalidateOtps(recipient, domain.OtpPurposeReauth); err != nil {
+			return err
+		}
+
+		var err error
+		code, _, err = tx.issueOtp(user.ID, recipient, domain.OtpPurposeReauth, ip)
+		if err != nil || bySMS {
+			return err
+		}
+		return tx.queueEmail(u.verificationEmail(recipient, user.Locale, code))
+	})
+	if err != nil || !bySMS {
+		return err
+	}
+	return u.sendOtpSMS(recipient, user.Locale, domain.OtpPurposeReauth, code)
+}
+
+// reauthRecipient picks where re-authentication codes go. It returns "" when
+// the account has no address a code can be delivered to.
+func (u *Usecase) reauthRecipient(user *domain.User) (string, bool) {
+	switch {
+	case user.Email != "":
+		return user.Email, false
+	case user.Phone != "" && u.smsEnabled():
+		return user.Phone, true
+	default:
+		return "", false
+	}
+}
+
+// Reauthenticate checks the user's password, a code from SendReauthOTP or a
+// two-factor code, and on success lets the session make sensitive changes
+// for reauthWindow. Wrong passwords count towards the account lockout like
+// failed logins.
+func (u *Usecase) Reauthenticate(userID, sessionID int, data inbound.Reauthenticate, client inbound.Client) error {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case data.Password != "":
+		if err := u.checkLoginThrottle(accountThrottleKey(user.ID), "account temporarily locked after too many failed attempts"); err != nil {
+			return err
+		}
+		if user.Password == "" {
+			return ErrIncorrectPassword
+		}
+		ok, err := u.repo.CheckPassword(user, data.Password)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			u.recordLoginFailure(user, client)
+			return ErrIncorrectPassword
+		}
+	case data.Otp != "":
+		recipient, _ := u.reauthRecipient(user)
+		otp, err := u.checkOtp(recipient, domain.OtpPurposeReauth, data.Otp)
+		if err != nil {
+			return err
+		}
+		if otp.UserID != user.ID {
+			return ErrInvalidOtp
+		}
+	case data.Code != "":
+		if err := u.checkSecondFactor(user.ID, data.Code, data.Code); err != nil {
+			return err
+		}
+	default:
+		return ErrReauthProofRequired
+	}
+
+	return u.repo.MarkSessionAuthenticated(sessionID)
+}
+
+// requireRecentAuth fails with ErrReauthRequired unless the user signed in or
+// re-authenticated on the session within reauthWindow.
+func (u *Usecase) requireRecentAuth(sessionID int) error {
+	session, err := u.repo.GetSession(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.AuthenticatedAt == nil || time.Since(*session.AuthenticatedAt) > reauthWindow {
+		return ErrReauthRequired
+	}
+	return nil
+}
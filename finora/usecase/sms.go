@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/sms"
+	"github.com/ayyoob-k-a/finora/utils"
+)
+
+var ErrSMSFailed = errors.New("could not send the text message, try again later")
+
+// smsEnabled reports whether codes can be sent to phone numbers
+func (u *Usecase) smsEnabled() bool {
+	return u.sms != nil
+}
+
+// checkSMSLimits enforces the hourly and daily caps on text messages to one
+// number. They apply on top of the OTP limits, since every message costs money
+// and a number can be targeted through several purposes.
+func (u *Usecase) checkSMSLimits(to string) error {
+	now := time.Now()
+	limits := []struct {
+		window time.Duration
+		max    int
+		reason string
+	}{
+		{time.Hour, u.smsConfig.MaxPerNumberHour, "too many text messages to this number"},
+		{24 * time.Hour, u.smsConfig.MaxPerNumberDay, "daily text message limit reached for this number"},
+	}
+
+	for _, limit := range limits {
+		if limit.max <= 0 {
+			continue
+		}
+		count, oldest, err := u.repo.CountSMSMessages(to, now.Add(-limit.window))
+		if err != nil {
+			return err
+		}
+		if count >= int64(limit.max) {
+			return &RateLimitError{Reason: limit.reason, RetryAfter: oldest.Add(limit.window).Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+// sendOtpSMS texts a one-time code to the number. Unlike email there is no
+// outbox: the code is short-lived, so the caller gets ErrSMSFailed straight
+// away and can ask again. Call it only once the transaction that issued the
+// code has committed, so no one receives a code that was rolled back and no
+// transaction is held open while the gateway answers.
+func (u *Usecase) sendOtpSMS(to, locale, purpose, code string) error {
+	return u.sendSMS(sms.OTP(to, locale, code, int(otpTTL/time.Minute)), purpose)
+}
+
+// sendSMS sends msg and records the attempt under purpose
+func (u *Usecase) sendSMS(msg sms.Message, purpose string) error {
+	to := msg.To
+	record := &domain.SMSMessage{Recipient: to, Purpose: purpose, Status: domain.SMSSent}
+
+	sendErr := u.sms.Send(msg)
+	if sendErr != nil {
+		record.Status, record.Error = domain.SMSFailed, sendErr.Error()
+		log.Printf("sms to %s failed: %v", utils.MaskIdentifier(to), sendErr)
+	}
+
+	if err := u.repo.CreateSMSMessage(record); err != nil {
+		log.Printf("failed to record sms to %s: %v", utils.MaskIdentifier(to), err)
+	}
+
+	if sendErr != nil {
+		return ErrSMSFailed
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/mailer"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// Failed logins in a row before each further attempt has to wait
+	// loginBackoffBase, doubling per failure up to loginBackoffMax.
+	loginBackoffAfter = 3
+	loginBackoffBase  = time.Second
+	loginBackoffMax   = 5 * time.Minute
+
+	// Accounts are locked after accountLockAfter failures in a row. Each lock
+	// lasts twice as long as the previous one, up to lockMax.
+	accountLockAfter = 10
+	accountLockBase  = 15 * time.Minute
+
+	// Addresses get a higher threshold since many users can share one
+	ipLockAfter = 50
+	ipLockBase  = time.Hour
+
+	lockMax = 24 * time.Hour
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid email/phone or password")
+	ErrInvalidUnlockToken = errors.New("invalid or expired unlock link")
+)
+
+func accountThrottleKey(userID int) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginThrottle refuses the attempt while the key is locked or still
+// inside its backoff delay.
+func (u *Usecase) checkLoginThrottle(key string, lockedReason string) error {
+	throttle, err := u.repo.GetLoginThrottle(key)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+		return &RateLimitError{Reason: lockedReason, RetryAfter: throttle.LockedUntil.Sub(now)}
+	}
+
+	if throttle.Failures >= loginBackoffAfter && throttle.LastFailureAt != nil {
+		next := throttle.LastFailureAt.Add(loginBackoff(throttle.Failures))
+		if now.Before(next) {
+			return &RateLimitError{Reason: "too many failed login attempts", RetryAfter: next.Sub(now)}
+		}
+	}
+
+	return nil
+}
+
+// recordLoginFailure counts a failed login against the client address and,
+// when known, the account. The account owner is emailed when it gets locked.
+func (u *Usecase) recordLoginFailure(user *domain.User, client inbound.Client) {
+	if _, _, err := u.repo.RecordLoginFailure(ipThrottleKey(client.IP), ipLockAfter, lockDuration(ipLockBase)); err != nil {
+		log.Printf("failed to record login failure for %s: %v", client.IP, err)
+	}
+
+	if user == nil {
+		return
+	}
+
+	throttle, locked, err := u.repo.RecordLoginFailure(accountThrottleKey(user.ID), accountLockAfter, lockDuration(accountLockBase))
+	if err != nil {
+		log.Printf("failed to record login failure for user %d: %v", user.ID, err)
+		return
+	}
+	if !locked {
+		return
+	}
+	u.audit(domain.AuditEvent{
+		Action:     domain.AuditAccountLocked,
+		TargetType: domain.AuditTargetUser,
+		TargetID:   &user.ID,
+		Payload:    map[string]any{"locked_until": throttle.LockedUntil},
+	}, client)
+	u.notifySecurity(user.ID, "Account locked", "Your account was locked after too many failed sign-in attempts.",
+		map[string]any{"event": domain.AuditAccountLocked, "locked_until": throttle.LockedUntil})
+	if user.Email != "" {
+		if err := u.sendLockoutNotice(user, throttle); err != nil {
+			log.Printf("failed to send lockout notice to user %d: %v", user.ID, err)
+		}
+	}
+}
+
+// sendLockoutNotice emails the owner of a locked account a link that lifts
+// the lock early.
+func (u *Usecase) sendLockoutNotice(user *domain.User, throttle *domain.LoginThrottle) error {
+	jti := utils.RandomID()
+	token, err := utils.GenerateActionToken(user.ID, utils.TokenTypeAccountUnlock, jti, *throttle.LockedUntil)
+	if err != nil {
+		return err
+	}
+	unlockURL := fmt.Sprintf("%s/auth/unlock?token=%s", strings.TrimRight(u.mail.URL, "/"), url.QueryEscape(token))
+
+	return u.inTx(func(tx *Usecase) error {
+		if err := tx.repo.SetUnlockJTI(throttle.Key, jti); err != nil {
+			return err
+		}
+
+		return tx.queueEmail(u.templates.Render(mailer.TemplateAccountLocked, user.Locale, user.Email, mailer.AccountLockedData{
+			UnlockURL:   unlockURL,
+			LockedUntil: mailer.FormatTime(*throttle.LockedUntil),
+		}))
+	})
+}
+
+// UnlockAccount lifts a lock using the link from the lockout email
+func (u *Usecase) UnlockAccount(token string, client inbound.Client) error {
+	claims, err := utils.ParseToken(token, utils.TokenTypeAccountUnlock)
+	if err != nil {
+		return ErrInvalidUnlockToken
+	}
+
+	ok, err := u.repo.UnlockLoginThrottle(accountThrottleKey(claims.UserID), claims.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidUnlockToken
+	}
+
+	u.auditSelf(claims.UserID, domain.AuditAccountUnlocked, client, nil)
+	return nil
+}
+
+// LoginThrottles lists failed-login counters for admins
+func (u *Usecase) LoginThrottles(limit, offset int) ([]domain.LoginThrottle, error) {
+	return u.repo.ListLoginThrottles(limit, offset)
+}
+
+func loginBackoff(failures int) time.Duration {
+	delay := loginBackoffBase << (failures - loginBackoffAfter)
+	if delay <= 0 || delay > loginBackoffMax {
+		return loginBackoffMax
+	}
+	return delay
+}
+
+func lockDuration(base time.Duration) func(lockCount int) time.Duration {
+	return func(lockCount int) time.Duration {
+		if lockCount > 10 {
+			return lockMax
+		}
+		d := base << lockCount
+		if d > lockMax {
+			return lockMax
+		}
+		return d
+	}
+}
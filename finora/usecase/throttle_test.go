@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+)
+
+const (
+	lockedAccountReason = "account temporarily locked after too many failed logins"
+	lockedNetworkReason = "too many failed logins from this network"
+	backoffReason       = "too many failed login attempts"
+)
+
+func (e *testEnv) login(identifier, password, ip string) error {
+	_, _, err := e.Login(inbound.Login{Identifier: identifier, Password: password}, inbound.Client{IP: ip, UserAgent: "test"})
+	return err
+}
+
+// setThrottle stores a failure streak for key whose last failure was ago
+func (e *testEnv) setThrottle(t *testing.T, key string, failures int, ago time.Duration) {
+	t.Helper()
+	last := time.Now().Add(-ago)
+	e.db.Delete(&domain.LoginThrottle{}, "key = ?", key)
+	err := e.db.Create(&domain.LoginThrottle{Key: key, Failures: failures, TotalFailures: failures, LastFailureAt: &last}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// wantWrongPassword fails unless err turns down the credentials rather than
+// the rate of attempts
+func wantWrongPassword(t *testing.T, err error) {
+	t.Helper()
+	var rateErr *RateLimitError
+	if err == nil || errors.As(err, &rateErr) {
+		t.Fatalf("error = %v, want the credentials refused", err)
+	}
+}
+
+func (e *testEnv) throttle(t *testing.T, key string) *domain.LoginThrottle {
+	t.Helper()
+	throttle, err := e.repo.GetLoginThrottle(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return throttle
+}
+
+func TestLoginBackoff(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "backoff@example.com")
+
+	for i := 0; i < loginBackoffAfter; i++ {
+		wantWrongPassword(t, env.login(user.Email, "wrong horse battery staple", client.IP))
+	}
+
+	// Even the right password has to wait out the delay
+	if rateErr := wantRateLimit(t, env.login(user.Email, testPassword, client.IP), backoffReason); rateErr.RetryAfter > loginBackoffBase {
+		t.Fatalf("retry after = %v, want at most %v", rateErr.RetryAfter, loginBackoffBase)
+	}
+
+	// Each further failure doubles the delay
+	env.setThrottle(t, accountThrottleKey(user.ID), loginBackoffAfter+2, 2*loginBackoffBase)
+	env.db.Delete(&domain.LoginThrottle{}, "key = ?", ipThrottleKey(client.IP))
+	wantRateLimit(t, env.login(user.Email, testPassword, client.IP), backoffReason)
+
+	env.setThrottle(t, accountThrottleKey(user.ID), loginBackoffAfter+2, 4*loginBackoffBase+time.Second)
+	if err := env.login(user.Email, testPassword, client.IP); err != nil {
+		t.Fatalf("login after the delay: %v", err)
+	}
+	if got := env.throttle(t, accountThrottleKey(user.ID)); got.Failures != 0 || got.LastFailureAt != nil {
+		t.Fatalf("streak after a successful login = %+v, want cleared", got)
+	}
+}
+
+func TestAccountLockout(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "locked@example.com")
+	key := accountThrottleKey(user.ID)
+
+	env.setThrottle(t, key, accountLockAfter-1, loginBackoffMax)
+	wantWrongPassword(t, env.login(user.Email, "wrong horse battery staple", client.IP))
+
+	locked := env.throttle(t, key)
+	if locked.LockedUntil == nil || locked.LockCount != 1 {
+		t.Fatalf("throttle = %+v, want locked once", locked)
+	}
+	if until := time.Until(*locked.LockedUntil); until <= accountLockBase-time.Minute || until > accountLockBase {
+		t.Fatalf("locked for %v, want %v", until, accountLockBase)
+	}
+
+	// Neither the right password nor another network gets in
+	wantRateLimit(t, env.login(user.Email, testPassword, client.IP), lockedAccountReason)
+	wantRateLimit(t, env.login(user.Email, testPassword, "198.51.100.7"), lockedAccountReason)
+
+	if email := env.lastOutboxEmail(t); email.Recipient != user.Email {
+		t.Fatalf("lockout notice sent to %q, want %q", email.Recipient, user.Email)
+	}
+
+	// The next lock lasts twice as long
+	env.db.Model(locked).Updates(map[string]any{"locked_until": nil, "failures": accountLockAfter - 1, "last_failure_at": time.Now().Add(-loginBackoffMax)})
+	wantWrongPassword(t, env.login(user.Email, "wrong horse battery staple", "198.51.100.7"))
+	if until := time.Until(*env.throttle(t, key).LockedUntil); until <= 2*accountLockBase-time.Minute {
+		t.Fatalf("second lock lasts %v, want %v", until, 2*accountLockBase)
+	}
+}
+
+func TestLoginPerIPBucket(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "shared@example.com")
+	const otherIP = "198.51.100.7"
+
+	// Guesses against unknown accounts still count against the address
+	wantWrongPassword(t, env.login("nobody@example.com", "wrong horse battery staple", client.IP))
+	if got := env.throttle(t, ipThrottleKey(client.IP)); got.Failures != 1 {
+		t.Fatalf("address failures = %d, want 1", got.Failures)
+	}
+
+	env.setThrottle(t, ipThrottleKey(client.IP), ipLockAfter-1, loginBackoffMax)
+	wantWrongPassword(t, env.login("nobody@example.com", "wrong horse battery staple", client.IP))
+
+	wantRateLimit(t, env.login(user.Email, testPassword, client.IP), lockedNetworkReason)
+	if err := env.login(user.Email, testPassword, otherIP); err != nil {
+		t.Fatalf("another address was locked out: %v", err)
+	}
+
+	// A valid login elsewhere does not clear the address
+	if got := env.throttle(t, ipThrottleKey(client.IP)); got.LockedUntil == nil {
+		t.Fatal("address lock was cleared by a successful login")
+	}
+}
@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token has already been used, please log in again")
+
+// issueTokens signs a new token pair for the session and records the refresh
+// token. parentJTI is empty for a fresh login.
+func (u *Usecase) issueTokens(user *domain.User, sessionID int, family, parentJTI string) (*response.AuthResponse, error) {
+	pair, err := utils.GenerateToken(user.ID, sessionID, family, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.repo.CreateRefreshToken(&domain.RefreshToken{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Family:    pair.Family,
+		JTI:       pair.RefreshID,
+		ParentJTI: parentJTI,
+		ExpiresAt: pair.RefreshExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &response.AuthResponse{
+		UserID:       user.ID,
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    int(utils.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. Each refresh token can be
+// used once; presenting one again revokes its whole family.
+func (u *Usecase) Refresh(data inbound.Refresh, client inbound.Client) (*response.AuthResponse, error) {
+	claims, err := utils.ParseToken(data.RefreshToken, utils.TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := u.repo.GetRefreshToken(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, utils.ErrInvalidToken
+		}
+		return nil, err
+	}
+	if token.RevokedAt != nil {
+		return nil, utils.ErrInvalidToken
+	}
+
+	ok, err := u.repo.MarkRefreshTokenUsed(token.JTI)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		log.Printf("refresh token reuse detected for user %d, revoking family %s", token.UserID, token.Family)
+		if err := u.repo.RevokeRefreshFamily(token.Family); err != nil {
+			return nil, err
+		}
+		if _, err := u.repo.RevokeSession(token.UserID, token.SessionID); err != nil {
+			return nil, err
+		}
+		u.audit(domain.AuditEvent{
+			Action:     domain.AuditRefreshReuse,
+			TargetType: domain.AuditTargetUser,
+			TargetID:   &token.UserID,
+			Payload:    map[string]any{"session_id": token.SessionID, "family": token.Family},
+		}, client)
+		u.notifySecurity(token.UserID, "Session signed out", "A sign-in token was used twice, so that session was signed out as a precaution.",
+			map[string]any{"event": domain.AuditRefreshReuse, "session_id": token.SessionID})
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := u.repo.GetUserByID(token.UserID)
+	if err != nil {
+		return nil, utils.ErrInvalidToken
+	}
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	session, err := u.repo.GetSession(token.SessionID)
+	if err != nil || session.RevokedAt != nil {
+		return nil, utils.ErrInvalidToken
+	}
+	if err := u.repo.TouchSession(session.ID, client.IP); err != nil {
+		return nil, err
+	}
+
+	u.auditSelf(user.ID, domain.AuditTokenRefresh, client, map[string]any{"session_id": session.ID})
+
+	return u.issueTokens(user, session.ID, token.Family, token.JTI)
+}
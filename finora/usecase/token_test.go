@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/utils"
+)
+
+// signIn starts a session for user and returns its tokens
+func (e *testEnv) signIn(t *testing.T, user *domain.User) *response.AuthResponse {
+	t.Helper()
+	res, err := e.startSession(user, client, loginMethodPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func (e *testEnv) refresh(token string) (*response.AuthResponse, error) {
+	return e.Refresh(inbound.Refresh{RefreshToken: token}, client)
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "refresh@example.com")
+	first := env.signIn(t, user)
+	other := env.signIn(t, user)
+
+	second, err := env.refresh(first.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh returned the same refresh token")
+	}
+	third, err := env.refresh(second.RefreshToken)
+	if err != nil {
+		t.Fatalf("rotated token refused: %v", err)
+	}
+
+	if _, err := env.refresh(first.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := env.refresh(third.RefreshToken); !errors.Is(err, utils.ErrInvalidToken) {
+		t.Fatalf("newest token of a revoked family: error = %v, want ErrInvalidToken", err)
+	}
+
+	var revoked, active int64
+	env.db.Model(&domain.Session{}).Where("revoked_at IS NOT NULL").Count(&revoked)
+	env.db.Model(&domain.Session{}).Where("revoked_at IS NULL").Count(&active)
+	if revoked != 1 || active != 1 {
+		t.Fatalf("%d sessions revoked and %d active, want only the reused one revoked", revoked, active)
+	}
+	if _, err := env.refresh(other.RefreshToken); err != nil {
+		t.Fatalf("another session's family was revoked: %v", err)
+	}
+}
+
+func TestRefreshRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   func(*testEnv, *testing.T, *response.AuthResponse) string
+		wantErr error
+	}{
+		{
+			name:  "access token",
+			token: func(_ *testEnv, _ *testing.T, res *response.AuthResponse) string { return res.AccessToken },
+		},
+		{
+			name:  "malformed token",
+			token: func(*testEnv, *testing.T, *response.AuthResponse) string { return "not-a-token" },
+		},
+		{
+			name: "token of a signed out session",
+			token: func(e *testEnv, t *testing.T, res *response.AuthResponse) string {
+				e.db.Model(&domain.Session{}).Where("user_id = ?", res.UserID).Update("revoked_at", time.Now())
+				return res.RefreshToken
+			},
+			wantErr: utils.ErrInvalidToken,
+		},
+		{
+			name: "token of a disabled account",
+			token: func(e *testEnv, t *testing.T, res *response.AuthResponse) string {
+				e.db.Model(&domain.User{}).Where("id = ?", res.UserID).Update("disabled_at", time.Now())
+				return res.RefreshToken
+			},
+			wantErr: ErrAccountDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			res := env.signIn(t, env.createUser(t, "refresh@example.com"))
+
+			_, err := env.refresh(tt.token(env, t, res))
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
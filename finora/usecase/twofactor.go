@@ -0,0 +1,246 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/model/response"
+	"github.com/ayyoob-k-a/finora/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	totpIssuer           = "Finora"
+	recoveryCodeCount    = 10
+	mfaChallengeTTL      = 5 * time.Minute
+	mfaMaxFailedAttempts = 5
+	mfaLockDuration      = 15 * time.Minute
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication is not set up")
+	ErrTwoFactorLocked         = errors.New("too many wrong codes, try again later")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge     = errors.New("invalid or expired mfa challenge")
+)
+
+// EnrollTwoFactor creates a new TOTP secret for the user. It is not used for
+// login until ConfirmTwoFactor succeeds.
+func (u *Usecase) EnrollTwoFactor(userID int) (*response.TwoFactorEnrollment, error) {
+	user, err := u.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := u.repo.GetTwoFactor(userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if tf != nil && tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if tf == nil {
+		tf = &domain.TwoFactor{UserID: userID}
+	}
+
+	tf.Secret, err = utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.SaveTwoFactor(tf); err != nil {
+		return nil, err
+	}
+
+	return &response.TwoFactorEnrollment{
+		Secret:     tf.Secret,
+		OtpauthURI: utils.TOTPURI(totpIssuer, accountLabel(user), tf.Secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables 2FA once the user proves their authenticator
+// produces valid codes. The returned recovery codes are only shown once.
+func (u *Usecase) ConfirmTwoFactor(userID int, code string, client inbound.Client) (*response.RecoveryCodes, error) {
+	tf, err := u.repo.GetTwoFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorNotEnrolled
+		}
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := utils.GenerateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, utils.HashSecret(code))
+	}
+
+	if err := u.repo.EnableTwoFactor(userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	u.auditSelf(userID, domain.AuditTwoFactorEnable, client, nil)
+	return &response.RecoveryCodes{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns 2FA off after checking a current TOTP or recovery code
+func (u *Usecase) DisableTwoFactor(userID int, code string, client inbound.Client) error {
+	if err := u.checkSecondFactor(userID, code, code); err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteTwoFactor(userID); err != nil {
+		return err
+	}
+
+	u.auditSelf(userID, domain.AuditTwoFactorDisable, client, nil)
+	u.notifySecurity(userID, "Two-factor authentication disabled", "Two-factor authentication was turned off for your account.",
+		map[string]any{"event": domain.AuditTwoFactorDisable})
+	return nil
+}
+
+// twoFactorEnabled reports whether login for the user needs a second factor
+func (u *Usecase) twoFactorEnabled(userID int) (bool, error) {
+	tf, err := u.repo.GetTwoFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return tf.Enabled, nil
+}
+
+// mfaChallenge issues the short-lived token that VerifyMFA exchanges once for
+// a real token pair.
+func (u *Usecase) mfaChallenge(userID int) (*response.MFAChallenge, error) {
+	jti := utils.RandomID()
+	expiresAt := time.Now().Add(mfaChallengeTTL)
+	token, err := utils.GenerateActionToken(userID, utils.TokenTypeMFAChallenge, jti, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.repo.CreateMFAChallenge(&domain.MFAChallenge{JTI: jti, UserID: userID, ExpiresAt: expiresAt}); err != nil {
+		return nil, err
+	}
+
+	return &response.MFAChallenge{
+		MFARequired:    true,
+		ChallengeToken: token,
+		ExpiresIn:      int(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+// VerifyMFA completes a two-factor login. Each challenge completes one login;
+// presenting it again fails with ErrInvalidMFAChallenge.
+func (u *Usecase) VerifyMFA(data inbound.VerifyMFA, client inbound.Client) (*response.AuthResponse, error) {
+	claims, err := utils.ParseToken(data.ChallengeToken, utils.TokenTypeMFAChallenge)
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	// Check the challenge is still pending before spending a code on it
+	challenge, err := u.repo.GetMFAChallenge(claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidMFAChallenge
+		}
+		return nil, err
+	}
+	if challenge.UserID != claims.UserID {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	if err := u.checkSecondFactor(claims.UserID, data.Code, data.RecoveryCode); err != nil {
+		return nil, err
+	}
+
+	consumed, err := u.repo.ConsumeMFAChallenge(claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := u.repo.GetUserByID(claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	return u.startSession(user, client, loginMethodMFA)
+}
+
+// checkSecondFactor accepts either a TOTP code or an unused recovery code.
+// Wrong codes count towards a temporary lock.
+func (u *Usecase) checkSecondFactor(userID int, code, recoveryCode string) error {
+	tf, err := u.repo.GetTwoFactor(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnrolled
+		}
+		return err
+	}
+	if !tf.Enabled {
+		return ErrTwoFactorNotEnrolled
+	}
+	if tf.LockedUntil != nil && time.Now().Before(*tf.LockedUntil) {
+		return ErrTwoFactorLocked
+	}
+
+	if code != "" {
+		if step, ok := utils.ValidateTOTP(tf.Secret, code, time.Now()); ok {
+			used, err := u.repo.UseTotpStep(userID, step)
+			if err != nil {
+				return err
+			}
+			if used {
+				return nil
+			}
+		}
+	}
+
+	if recoveryCode != "" {
+		used, err := u.repo.UseRecoveryCode(userID, utils.HashSecret(strings.ToLower(strings.TrimSpace(recoveryCode))))
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+
+	if err := u.repo.RecordTwoFactorFailure(userID, mfaMaxFailedAttempts, mfaLockDuration); err != nil {
+		return err
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+// accountLabel picks the name shown for the account in authenticator apps
+func accountLabel(user *domain.User) string {
+	switch {
+	case user.Email != "":
+		return user.Email
+	case user.Phone != "":
+		return user.Phone
+	default:
+		return user.Username
+	}
+}
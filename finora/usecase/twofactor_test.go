@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/domain"
+	"github.com/ayyoob-k-a/finora/model/inbound"
+	"github.com/ayyoob-k-a/finora/utils"
+)
+
+// enableTwoFactor turns on 2FA for user and returns two recovery codes
+func (e *testEnv) enableTwoFactor(t *testing.T, user *domain.User) (string, string) {
+	t.Helper()
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.db.Create(&domain.TwoFactor{UserID: user.ID, Secret: secret, Enabled: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	codes := []string{"aaaaa-aaaaa", "bbbbb-bbbbb"}
+	for _, code := range codes {
+		if err := e.db.Create(&domain.RecoveryCode{UserID: user.ID, CodeHash: utils.HashSecret(code)}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return codes[0], codes[1]
+}
+
+func TestVerifyMFAChallengeIsSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "mfa@example.com")
+	first, second := env.enableTwoFactor(t, user)
+
+	_, challenge, err := env.Login(inbound.Login{Identifier: user.Email, Password: testPassword}, client)
+	if err != nil || challenge == nil {
+		t.Fatalf("login = %v, %v, want a challenge", challenge, err)
+	}
+
+	// A wrong code does not use the challenge up
+	_, err = env.VerifyMFA(inbound.VerifyMFA{ChallengeToken: challenge.ChallengeToken, RecoveryCode: "zzzzz-zzzzz"}, client)
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: error = %v, want ErrInvalidTwoFactorCode", err)
+	}
+
+	if _, err := env.VerifyMFA(inbound.VerifyMFA{ChallengeToken: challenge.ChallengeToken, RecoveryCode: first}, client); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = env.VerifyMFA(inbound.VerifyMFA{ChallengeToken: challenge.ChallengeToken, RecoveryCode: second}, client)
+	if !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("replayed challenge: error = %v, want ErrInvalidMFAChallenge", err)
+	}
+	var unused int64
+	env.db.Model(&domain.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&unused)
+	if unused != 1 {
+		t.Fatalf("%d recovery codes left, want the replay not to spend one", unused)
+	}
+}
+
+func TestVerifyMFARejectsUnknownChallenge(t *testing.T) {
+	env := newTestEnv(t)
+	user := env.createUser(t, "mfa@example.com")
+	code, _ := env.enableTwoFactor(t, user)
+
+	// Correctly signed, but never handed out by a login
+	token, err := utils.GenerateActionToken(user.ID, utils.TokenTypeMFAChallenge, utils.RandomID(), time.Now().Add(mfaChallengeTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = env.VerifyMFA(inbound.VerifyMFA{ChallengeToken: token, RecoveryCode: code}, client)
+	if !errors.Is(err, ErrInvalidMFAChallenge) {
+		t.Fatalf("error = %v, want ErrInvalidMFAChallenge", err)
+	}
+}
@@ -0,0 +1,422 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	minRSAKeyBits = 2048
+	// keyReloadInterval is how often the key directory is re-read, so keys
+	// rotated by another instance are picked up.
+	keyReloadInterval = time.Minute
+	// keyActivationDelay is how long a new key is only published before it
+	// signs tokens. By then every instance has reloaded the key directory and
+	// can verify what the key signs.
+	keyActivationDelay = 2 * keyReloadInterval
+
+	// rotationLockFile in the key directory is held by the instance that is
+	// rotating. A lock older than rotationLockStale was left by an instance
+	// that died while rotating and is broken.
+	rotationLockFile  = ".rotate.lock"
+	rotationLockStale = 10 * time.Minute
+)
+
+var ErrNoSigningKey = errors.New("no usable JWT signing key found, provision one in JWT_KEY_DIR or set JWT_PRIVATE_KEY")
+
+// signingKey is one private key of the keyring, identified by its kid
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// keyring holds every key tokens may be verified with. Tokens are signed with
+// one key only, see current.
+type keyring struct {
+	mu     sync.RWMutex
+	keys   map[string]*signingKey
+	sorted []*signingKey // newest first
+	issuer string
+}
+
+var signingKeys = &keyring{}
+
+// InitSigningKeys loads the signing keys and, when a rotation interval and key
+// directory are configured, starts rotating them in the background. It fails
+// when no usable key is found, unless cfg.GenerateKey allows creating one.
+func InitSigningKeys(cfg configs.JWT) error {
+	if cfg.PrivateKey == "" && cfg.KeyDir == "" {
+		return ErrNoSigningKey
+	}
+	if cfg.KeyDir != "" && !filepath.IsAbs(cfg.KeyDir) && !cfg.GenerateKey {
+		return fmt.Errorf("JWT_KEY_DIR must be an absolute path, got %q", cfg.KeyDir)
+	}
+
+	if err := reloadSigningKeys(cfg); err != nil {
+		return err
+	}
+
+	if signingKeys.current() == nil && cfg.KeyDir != "" && cfg.GenerateKey {
+		log.Printf("no signing key in %s, generating one because JWT_GENERATE_KEY is set", cfg.KeyDir)
+		if err := generateSigningKey(cfg); err != nil {
+			return err
+		}
+		if err := reloadSigningKeys(cfg); err != nil {
+			return err
+		}
+	}
+
+	if signingKeys.current() == nil {
+		return ErrNoSigningKey
+	}
+
+	if cfg.KeyDir != "" && cfg.RotationInterval > 0 {
+		go rotateSigningKeys(cfg)
+	}
+
+	return nil
+}
+
+// reloadSigningKeys replaces the keyring with the keys found in the config
+func reloadSigningKeys(cfg configs.JWT) error {
+	keys := map[string]*signingKey{}
+
+	if cfg.PrivateKey != "" {
+		key, err := parseSigningKey(cfg.KeyID, []byte(cfg.PrivateKey), time.Time{})
+		if err != nil {
+			return fmt.Errorf("JWT_PRIVATE_KEY: %w", err)
+		}
+		keys[key.id] = key
+	}
+
+	if cfg.KeyDir != "" {
+		if cfg.GenerateKey {
+			if err := os.MkdirAll(cfg.KeyDir, 0o700); err != nil {
+				return err
+			}
+		} else if _, err := os.Stat(cfg.KeyDir); err != nil {
+			return fmt.Errorf("JWT_KEY_DIR: %w", err)
+		}
+
+		paths, err := filepath.Glob(filepath.Join(cfg.KeyDir, "*.pem"))
+		if err != nil {
+			return err
+		}
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			key, err := parseSigningKey(strings.TrimSuffix(filepath.Base(path), ".pem"), data, info.ModTime())
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			keys[key.id] = key
+		}
+	}
+
+	signingKeys.set(keys, cfg.Issuer)
+	return nil
+}
+
+// rotateSigningKeys reloads the key directory every keyReloadInterval and
+// rotates the keys when they are due. Old keys stay available for
+// verification until every token they signed has expired, then they are
+// deleted.
+func rotateSigningKeys(cfg configs.JWT) {
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := reloadSigningKeys(cfg); err != nil {
+			log.Printf("failed to reload signing keys: %v", err)
+			continue
+		}
+		if err := rotateIfDue(cfg); err != nil {
+			log.Printf("failed to rotate signing key: %v", err)
+		}
+	}
+}
+
+// rotateIfDue publishes a new key once the newest key is older than the
+// rotation interval. The new key starts signing keyActivationDelay later.
+// Only the instance holding the rotation lock generates it; the others pick
+// it up on their next reload.
+func rotateIfDue(cfg configs.JWT) error {
+	if !signingKeys.rotationDue(cfg.RotationInterval) {
+		return nil
+	}
+
+	unlock, locked, err := lockKeyRotation(cfg.KeyDir)
+	if err != nil || !locked {
+		return err
+	}
+	defer unlock()
+
+	// Another instance may have rotated just before we got the lock
+	if err := reloadSigningKeys(cfg); err != nil {
+		return err
+	}
+	if !signingKeys.rotationDue(cfg.RotationInterval) {
+		return nil
+	}
+
+	if err := generateSigningKey(cfg); err != nil {
+		return err
+	}
+	pruneSigningKeys(cfg)
+	return reloadSigningKeys(cfg)
+}
+
+// lockKeyRotation creates the rotation lock file in dir. It reports false
+// when another instance holds the lock.
+func lockKeyRotation(dir string) (unlock func(), locked bool, err error) {
+	path := filepath.Join(dir, rotationLockFile)
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		info, statErr := os.Stat(path)
+		if statErr != nil || time.Since(info.ModTime()) < rotationLockStale {
+			return nil, false, nil
+		}
+		log.Printf("breaking stale signing key rotation lock %s", path)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, false, err
+		}
+		file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			return nil, false, nil
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Name the holder for whoever finds a stale lock
+	hostname, _ := os.Hostname()
+	fmt.Fprintf(file, "%s %d\n", hostname, os.Getpid())
+	file.Close()
+
+	return func() {
+		if err := os.Remove(path); err != nil {
+			log.Printf("failed to release signing key rotation lock %s: %v", path, err)
+		}
+	}, true, nil
+}
+
+// pruneSigningKeys deletes key files that can no longer have signed a valid token
+func pruneSigningKeys(cfg configs.JWT) {
+	retireAfter := cfg.RotationInterval + keyActivationDelay + RefreshTokenTTL
+
+	paths, err := filepath.Glob(filepath.Join(cfg.KeyDir, "*.pem"))
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || time.Since(info.ModTime()) < retireAfter {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("failed to remove retired signing key %s: %v", path, err)
+		}
+	}
+}
+
+// generateSigningKey writes a new PKCS#8 key to the key directory
+func generateSigningKey(cfg configs.JWT) error {
+	var private crypto.Signer
+	var err error
+
+	switch cfg.Algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, minRSAKeyBits)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return fmt.Errorf("unsupported JWT_ALGORITHM %q, use RS256 or EdDSA", cfg.Algorithm)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+
+	// Write under a name the reload ignores, so no instance reads a half
+	// written key
+	kid := time.Now().UTC().Format("20060102T150405") + "-" + RandomID()[:8]
+	path := filepath.Join(cfg.KeyDir, kid+".pem")
+	if err := os.WriteFile(path+".tmp", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return err
+	}
+
+	log.Printf("generated new %s signing key %s", cfg.Algorithm, kid)
+	return nil
+}
+
+func parseSigningKey(kid string, data []byte, createdAt time.Time) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: kid, createdAt: createdAt}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+		}
+		key.method, key.private = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.method, key.private = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+
+	return key, nil
+}
+
+func (k *keyring) set(keys map[string]*signingKey, issuer string) {
+	sorted := make([]*signingKey, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].createdAt.Equal(sorted[j].createdAt) {
+			return sorted[i].id > sorted[j].id
+		}
+		return sorted[i].createdAt.After(sorted[j].createdAt)
+	})
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+	k.sorted = sorted
+	k.issuer = issuer
+}
+
+// current returns the key new tokens are signed with: the newest key that
+// has been published for keyActivationDelay. Until one has, e.g. right after
+// the first key was generated, the oldest key is used.
+func (k *keyring) current() *signingKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if len(k.sorted) == 0 {
+		return nil
+	}
+	activeFrom := time.Now().Add(-keyActivationDelay)
+	for _, key := range k.sorted {
+		if !key.createdAt.After(activeFrom) {
+			return key
+		}
+	}
+	return k.sorted[len(k.sorted)-1]
+}
+
+// rotationDue reports whether the newest key, active or not, is older than
+// interval
+func (k *keyring) rotationDue(interval time.Duration) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.sorted) == 0 || time.Since(k.sorted[0].createdAt) >= interval
+}
+
+func (k *keyring) lookup(kid string) (*signingKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *keyring) issuerName() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.issuer
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS returns the public half of every key tokens may be verified with
+func PublicJWKS() JWKS {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range signingKeys.keys {
+		jwk := JWK{Kid: key.id, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+
+	return set
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ayyoob-k-a/finora/configs"
+)
+
+func testKeyConfig(t *testing.T) configs.JWT {
+	t.Helper()
+	return configs.JWT{
+		Issuer:           "finora-test",
+		Algorithm:        "EdDSA",
+		KeyDir:           t.TempDir(),
+		RotationInterval: time.Hour,
+	}
+}
+
+// ageKeys backdates every key file in dir by age and reloads the keyring
+func ageKeys(t *testing.T, cfg configs.JWT, age time.Duration) {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(cfg.KeyDir, "*.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(-age)
+	for _, path := range paths {
+		if err := os.Chtimes(path, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := reloadSigningKeys(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func keyCount(t *testing.T, cfg configs.JWT) int {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(cfg.KeyDir, "*.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(paths)
+}
+
+func TestNewKeySignsOnlyAfterActivationDelay(t *testing.T) {
+	cfg := testKeyConfig(t)
+	if err := generateSigningKey(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ageKeys(t, cfg, 0)
+
+	// The only key signs straight away, nothing else could
+	first := signingKeys.current()
+	if first == nil {
+		t.Fatal("no key in use")
+	}
+
+	ageKeys(t, cfg, 2*time.Hour)
+	if err := rotateIfDue(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if n := keyCount(t, cfg); n != 2 {
+		t.Fatalf("%d keys after rotation, want 2", n)
+	}
+	if got := signingKeys.current(); got.id != first.id {
+		t.Fatalf("signing with %s right after rotation, want %s until every instance has the new key", got.id, first.id)
+	}
+	if _, ok := signingKeys.lookup(signingKeys.sorted[0].id); !ok {
+		t.Fatal("new key not published for verification")
+	}
+
+	// A published key that is not yet signing does not trigger another rotation
+	if err := rotateIfDue(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if n := keyCount(t, cfg); n != 2 {
+		t.Fatalf("%d keys after a second check, want 2", n)
+	}
+
+	newest := signingKeys.sorted[0]
+	at := time.Now().Add(-keyActivationDelay - time.Second)
+	if err := os.Chtimes(filepath.Join(cfg.KeyDir, newest.id+".pem"), at, at); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloadSigningKeys(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := signingKeys.current(); got.id != newest.id {
+		t.Fatalf("signing with %s after the activation delay, want %s", got.id, newest.id)
+	}
+}
+
+func TestOnlyLockHolderRotates(t *testing.T) {
+	cfg := testKeyConfig(t)
+	if err := generateSigningKey(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ageKeys(t, cfg, 2*time.Hour)
+
+	unlock, locked, err := lockKeyRotation(cfg.KeyDir)
+	if err != nil || !locked {
+		t.Fatalf("lock = %v, %v", locked, err)
+	}
+	if _, again, err := lockKeyRotation(cfg.KeyDir); err != nil || again {
+		t.Fatalf("second lock = %v, %v, want it refused", again, err)
+	}
+	if err := rotateIfDue(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if n := keyCount(t, cfg); n != 1 {
+		t.Fatalf("%d keys while another instance holds the lock, want 1", n)
+	}
+
+	unlock()
+	if err := rotateIfDue(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if n := keyCount(t, cfg); n != 2 {
+		t.Fatalf("%d keys once the lock is free, want 2", n)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.KeyDir, rotationLockFile)); !os.IsNotExist(err) {
+		t.Fatalf("rotation lock left behind: %v", err)
+	}
+}
+
+func TestStaleRotationLockIsBroken(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, rotationLockFile)
+	if err := os.WriteFile(path, []byte("gone 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Now().Add(-rotationLockStale - time.Minute)
+	if err := os.Chtimes(path, at, at); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, locked, err := lockKeyRotation(dir)
+	if err != nil || !locked {
+		t.Fatalf("lock over a stale one = %v, %v", locked, err)
+	}
+	unlock()
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	TokenTypeAccess        = "access"
+	TokenTypeRefresh       = "refresh"
+	TokenTypePasswordReset = "password_reset"
+	TokenTypeMFAChallenge  = "mfa_challenge"
+	TokenTypeAccountUnlock = "account_unlock"
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// TokenClaims are the claims carried by every token issued by GenerateToken
+type TokenClaims struct {
+	UserID    int    `json:"user_id"`
+	SessionID int    `json:"sid"`
+	Type      string `json:"typ"`
+	Family    string `json:"fam,omitempty"`
+	Role      string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenPair is an access token together with the refresh token issued
+// alongside it. RefreshID and Family identify the refresh token server side.
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshID        string
+	Family           string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// GenerateToken issues a short-lived access token and a refresh token for the
+// session. The refresh token belongs to the given family; an empty family
+// starts a new one. role is embedded in the access token.
+func GenerateToken(userID, sessionID int, family, role string) (*TokenPair, error) {
+	now := time.Now()
+
+	if family == "" {
+		family = RandomID()
+	}
+
+	pair := &TokenPair{
+		RefreshID:        RandomID(),
+		Family:           family,
+		AccessExpiresAt:  now.Add(AccessTokenTTL),
+		RefreshExpiresAt: now.Add(RefreshTokenTTL),
+	}
+
+	var err error
+	pair.AccessToken, err = signToken(TokenClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Type:      TokenTypeAccess,
+		Role:      role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        RandomID(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(pair.AccessExpiresAt),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pair.RefreshToken, err = signToken(TokenClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Type:      TokenTypeRefresh,
+		Family:    family,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        pair.RefreshID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(pair.RefreshExpiresAt),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pair, nil
+}
+
+// GenerateActionToken signs a single-purpose token, such as a password reset
+// link. id should reference a server-side record that makes the token single
+// use.
+func GenerateActionToken(userID int, tokenType string, id string, expiresAt time.Time) (string, error) {
+	return signToken(TokenClaims{
+		UserID: userID,
+		Type:   tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+}
+
+// signToken signs with the active key and names it in the kid header so
+// verifiers can pick the matching public key.
+func signToken(claims TokenClaims) (string, error) {
+	key := signingKeys.current()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	claims.Issuer = signingKeys.issuerName()
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// ParseToken verifies the signature and expiry of a token and checks that it
+// is of the expected type.
+func ParseToken(tokenString string, tokenType string) (*TokenClaims, error) {
+	var claims TokenClaims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := signingKeys.lookup(kid)
+		if !ok || key.method.Alg() != t.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		return key.private.Public(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(signingKeys.issuerName()),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if claims.Type != tokenType || claims.UserID == 0 {
+		return nil, ErrInvalidToken
+	}
+	if (tokenType == TokenTypeAccess || tokenType == TokenTypeRefresh) && claims.SessionID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return &claims, nil
+}
+
+// RandomID returns a random 128-bit identifier encoded as hex
+func RandomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// HashSecret hashes a high-entropy secret, such as a recovery code, for storage
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// PATPrefix marks personal access tokens so they can be told apart from JWTs
+const PATPrefix = "fin_pat_"
+
+// GeneratePAT returns a new personal access token
+func GeneratePAT() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return PATPrefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,37 @@
+package utils
+
+import "testing"
+
+func TestNormalizeIdentifier(t *testing.T) {
+	tests := []struct {
+		identifier string
+		want       string
+		valid      bool
+	}{
+		{identifier: " Ada@Example.com ", want: "ada@example.com", valid: true},
+		{identifier: "+14155552671", want: "+14155552671", valid: true},
+		{identifier: "14155552671", want: "+14155552671", valid: true},
+		{identifier: "+1 (415) 555-2671", want: "+14155552671", valid: true},
+		{identifier: "1.415.555.2671", want: "+14155552671", valid: true},
+		{identifier: "+0123456789", want: "+0123456789"},
+		{identifier: "12345", want: "+12345"},
+		{identifier: "+1415555267123456", want: "+1415555267123456"},
+		{identifier: "not a number", want: "not a number"},
+		{identifier: "+1-800-FLOWERS", want: "+1-800-FLOWERS"},
+		{identifier: "Ada <ada@example.com>", want: "ada <ada@example.com>"},
+		{identifier: "ada@", want: "ada@"},
+		{identifier: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.identifier, func(t *testing.T) {
+			got := NormalizeIdentifier(tt.identifier)
+			if got != tt.want {
+				t.Fatalf("NormalizeIdentifier(%q) = %q, want %q", tt.identifier, got, tt.want)
+			}
+			if valid := ValidIdentifier(got); valid != tt.valid {
+				t.Fatalf("ValidIdentifier(%q) = %v, want %v", got, valid, tt.valid)
+			}
+		})
+	}
+}